@@ -20,22 +20,37 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/big"
 	"math/rand"
 	"os"
+	"os/signal"
 	"path"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"text/template"
 	"time"
 
 	// CaltechLibrary Packages
 	"github.com/caltechlibrary/cli"
 	"github.com/caltechlibrary/shelltools"
+	"github.com/caltechlibrary/shelltools/ranges"
 )
 
 var (
-	usage = `USAGE: %s [OPTIONS] START_INTEGER END_INTEGER [INCREMENT_INTEGER]`
+	usage = `USAGE: %s [OPTIONS] START END [INCREMENT]
+       %s [OPTIONS] EXPRESSION
+       %s [OPTIONS] -product EXPRESSION [EXPRESSION ...]
+       %s [OPTIONS] -source lines|json [START [END [INCREMENT]]]
+       %s [OPTIONS] -set OPERATION SET [SET ...]`
 
 	description = `
 SYNOPSIS
@@ -47,6 +62,109 @@ subset of functionality found in the Unix seq command.
 
 If the first argument is greater than the last then it counts 
 down otherwise it counts up.
+
+When the start, end or increment has a decimal point (or exponent)
+a decimal range is produced. Each value is computed exactly as start
+plus a multiple of the increment so there is no floating point
+drift. Values are written with as many decimal places as the
+arguments use unless -precision or a printf style -format (e.g.
+"%%.3f", "%%g") is given.
+
+When the start and end contain letters an alphabetic range is
+produced. Letters carry like spreadsheet columns, "A" to "AC" is A,
+B, ..., Z, AA, AB, AC. Values mixing letters and digits, like "A01",
+count like an odometer with each position keeping its kind, "A98" to
+"B01" is A98, A99, B00, B01. The case of the start is kept. Use
+-alphabet to supply your own ordered letters, e.g. leaving out I and
+O. Increments and counting down work as they do for integers.
+
+Each value can be written with -format using printf verbs (e.g.
+"scan_%%04d.tif") or a Go text/template (e.g. "{{.Index}},{{.Value}}")
+where .Value is the value as text, .Raw the number (or letters) and
+.Index its position from 0. With -pad numbers are zero padded to the
+width of the widest value in the range, or in a range expression
+(brace templates are padded with a leading zero instead). Values are
+separated by a space unless -separator gives another (\t and \n are
+understood), -newline puts each value on its own line.
+
+With -random one value is picked from the range, -sample N picks N
+different values and -shuffle writes all of them in a random order.
+The values are computed as they are picked so even very large ranges
+need very little memory. Use -seed to repeat the same choices.
+
+Integers too large for Go's int, like 128 bit identifiers, are
+handled with arbitrary precision arithmetic. Ranges ending at the
+largest or smallest int stop there rather than wrapping around.
+
+A single argument is read as a range expression. Comma separated
+segments are a value, START..END or START..END..STEP, segments
+starting with "!" are left out, e.g. "1..10..2,20,30..35" or
+"1..100,!50..59". Text with braces is expanded like bash, "a{1..3}b"
+is a1b a2b a3b and "{x,y}{01..02}" is x01 x02 y01 y02. A leading
+zero pads the values to the same width.
+
+For batch jobs -chunks N divides the range into N parts of nearly
+equal size and writes the first and last value of each part,
+separated by a space, one part per line. -chunk-size K writes parts
+of K values, the last may be shorter. Together the parts cover the
+range without gaps or overlaps. -worker I/N writes only part I
+(counting from 1) of N, handy in job arrays, nothing is written when
+part I is empty.
+
+With -count N the range is N values evenly spaced from start to end,
+both included. -geometric RATIO multiplies each value by RATIO until
+end is passed, e.g. powers of two. -log with -count N spaces N values
+evenly on a logarithmic scale. These values are integers when start
+and end are, otherwise they have as many decimal places as start and
+end use. More places are added when neighbouring values would round
+to the same value, e.g. "-count 3 0 1" is 0.0 0.5 1.0. Use
+-precision to set the decimal places and -round to pick nearest
+(halves away from zero), even (halves to even), down or up. -round
+also applies to decimal ranges.
+
+With -product each argument is a range expression (or brace template)
+and the values are every combination of one value from each, e.g.
+years by months. Values in a combination are joined by -delimiter
+(a comma by default) or, with -json, written as a JSON array. The
+last expression changes fastest, like nested loops, unless -order
+lists the expressions (counting from 1) from slowest to fastest.
+-index N writes only the value at position N (counting from 0) of
+any range, so large grids can be read without listing them.
+
+Integers can be written in another base with -base (2 to 36) or as
+Roman numerals with -roman, start, end and increment are then read
+in that notation too. Values like "0x1F", "0o17" and "0b101" are
+recognised without the options. Roman numerals need -roman, without
+it "cd" to "cm" counts letters like any other alphabetic range. -prefix
+adds 0b, 0o or 0x to binary, octal and hexadecimal values and -case
+upper or lower sets the case of letters, otherwise they follow the
+start and end values. -base and -roman also apply to range
+expressions, -product and -set, e.g. "-roman 1..5" is I II III IV V.
+
+With -source lines the line numbers of text read from -input (or
+standard input) are written, with -source json the indexes of a JSON
+array or the keys of a JSON object, in the order they appear. -values
+writes the lines or JSON values in their place. START, END and
+INCREMENT then pick a slice, positions count from 1 for lines and from
+0 for JSON, negative positions count back from the end (-1 is the
+last) and a START after END counts down. -random, -sample, -shuffle
+and -index pick from the slice.
+
+Output is buffered and written as it is produced, so very long ranges
+start at once and need little memory. When the reader goes away, e.g.
+piped to head, %s stops quietly. -limit N stops after N values and
+-until-stdin-closes stops once standard input is closed, so a
+long range can run for as long as another program needs it.
+
+-set union, intersection or difference combines sets of integers,
+difference keeps the integers of the first set found in none of the
+others. -set complement writes the integers missing between the
+smallest and largest of the sets, e.g. gaps in a list of accession
+numbers. Each SET is a range expression, @FILE for a file with one
+integer per line or - for such a list on standard input. The result
+is written value by value or, with -compact, as merged ranges like
+"1-40,45,50-99". Large ranges counting by one are combined without
+listing them, other sets are limited to about a million integers.
 `
 
 	examples = `
@@ -71,6 +189,117 @@ Yields 10 9 8 7 6 5 4 3 2 1
 	%s -r 0 10
 
 Yields a random integer from 0 to 10
+
+	%s 0 1 0.25
+
+Yields 0.00 0.25 0.50 0.75 1.00
+
+	%s -precision 1 0.1 0.5 0.1
+
+Yields 0.1 0.2 0.3 0.4 0.5
+
+	%s x ad
+
+Yields x y z aa ab ac ad
+
+	%s -alphabet ABCDEFGHJKLMNPQRSTUVWXYZ H98 J01
+
+Yields H98 H99 J00 J01
+
+	%s -format "scan_%%04d.tif" -newline 1 3
+
+Yields scan_0001.tif, scan_0002.tif and scan_0003.tif on separate
+lines
+
+	%s -pad -separator , 8 10
+
+Yields 08,09,10
+
+	%s -sample 3 -seed 42 1 2000000000
+
+Yields three different integers from 1 to 2000000000, the same
+three each time it is run with -seed 42
+
+	%s 18446744073709551615 18446744073709551617
+
+Yields 18446744073709551615 18446744073709551616 18446744073709551617
+
+	%s "1..10..2,20,30..35,!33"
+
+Yields 1 3 5 7 9 20 30 31 32 34 35
+
+	%s "scan_{001..003}.tif"
+
+Yields scan_001.tif scan_002.tif scan_003.tif
+
+	%s -chunks 3 1 10
+
+Yields "1 3", "4 6" and "7 10" on separate lines
+
+	%s -worker 2/4 1 1000
+
+Yields 251 500
+
+	%s -count 5 -precision 2 0 1
+
+Yields 0.00 0.25 0.50 0.75 1.00
+
+	%s -geometric 2 1 1024
+
+Yields 1 2 4 8 16 32 64 128 256 512 1024
+
+	%s -log -count 4 1 1000
+
+Yields 1 10 100 1000
+
+	%s -product -newline 2020..2021 01..02
+
+Yields 2020,01 2020,02 2021,01 and 2021,02 on separate lines
+
+	%s -product -json -order 2,1 a,b 1..2
+
+Yields ["a",1] ["b",1] ["a",2] ["b",2]
+
+	%s -product -index 7 2000..2099 01..12
+
+Yields 2000,08
+
+	%s 0xe 0x11
+
+Yields 0xe 0xf 0x10 0x11
+
+	%s -base 2 -pad 0 11
+
+Yields 00 01 10 11
+
+	%s -roman viii xii
+
+Yields viii ix x xi xii
+
+	cat list.txt | %s -source lines -values -- -3 -1
+
+Yields the last three lines of list.txt
+
+	echo '{"a":1,"b":[2,3]}' | %s -source json -values
+
+Yields 1 [2,3]
+
+	%s -newline 1 1000000000 | head -3
+
+Yields 1, 2 and 3 on separate lines and stops
+
+	%s -limit 3 -geometric 2 1 1024
+
+Yields 1 2 4
+
+	%s -set difference -compact 1..5000 @found.txt
+
+Yields the numbers from 1 to 5000 missing from found.txt, e.g.
+"1-40,45-99"
+
+	printf "3\n1\n7\n" | %s -set complement -
+
+Yields 2 4 5 6
 `
 
 	// Standard Options
@@ -79,17 +308,63 @@ Yields a random integer from 0 to 10
 	showLicense bool
 
 	// Application Specific Options
-	start         int
-	end           int
-	increment     int
+	start         string
+	end           string
+	increment     = "1"
 	randomElement bool
+	precision     = -1
+	format        string
+	alphabet      = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	pad           bool
+	separator     = " "
+	newline       bool
+	sample        int
+	shuffle       bool
+	seed          int64
+	chunks        int
+	chunkSize     int
+	worker        string
+	count         int
+	geometric     string
+	logScale      bool
+	round         = "nearest"
+	product       bool
+	delimiter     = ","
+	asJSON        bool
+	order         string
+	index         string
+	base          int
+	prefix        bool
+	letterCase    string
+	roman         bool
+	source        string
+	input         string
+	showValues    bool
+	limit         int
+	untilClosed   bool
+	setOperation  string
+	compact       bool
+
+	// out buffers standard output
+	out = bufio.NewWriter(os.Stdout)
+	// stdinClosed is set to 1 by -until-stdin-closes at end of input
+	stdinClosed int32
+
+	// rounding is the -round mode
+	rounding ranges.Rounding
+
+	// rng is the source of random values, seeded by -seed
+	rng *rand.Rand
+
+	// tmpl is set when -format is a Go template
+	tmpl *template.Template
 )
 
 func init() {
 	const (
-		startUsage = "The starting integer."
-		endUsage   = "The ending integer."
-		incUsage   = "The non-zero integer increment value."
+		startUsage = "The starting value."
+		endUsage   = "The ending value."
+		incUsage   = "The non-zero increment value."
 	)
 
 	// Standard Options
@@ -101,31 +376,583 @@ func init() {
 	flag.BoolVar(&showVersion, "version", false, "display version")
 
 	// App specific options
-	flag.IntVar(&start, "start", 0, startUsage)
-	flag.IntVar(&start, "s", 0, startUsage)
-	flag.IntVar(&end, "end", 0, endUsage)
-	flag.IntVar(&end, "e", 0, endUsage)
-	flag.IntVar(&increment, "increment", 1, incUsage)
-	flag.IntVar(&increment, "i", 1, incUsage)
+	flag.StringVar(&start, "start", "", startUsage)
+	flag.StringVar(&start, "s", "", startUsage)
+	flag.StringVar(&end, "end", "", endUsage)
+	flag.StringVar(&end, "e", "", endUsage)
+	flag.StringVar(&increment, "increment", increment, incUsage)
+	flag.StringVar(&increment, "i", increment, incUsage)
 	flag.BoolVar(&randomElement, "r", false, "Pick a range value from range")
 	flag.BoolVar(&randomElement, "random", false, "Pick a range value from range")
+	flag.IntVar(&precision, "precision", precision, "Number of decimal places for decimal ranges.")
+	flag.StringVar(&format, "format", "", "printf style format (e.g. %03d, %.2f) or Go template (e.g. {{.Index}}:{{.Value}}) for each value")
+	flag.BoolVar(&pad, "pad", false, "Zero pad numbers to the width of the widest value.")
+	flag.StringVar(&separator, "separator", separator, "Text written between values.")
+	flag.BoolVar(&newline, "newline", false, "Write each value on its own line.")
+	flag.IntVar(&sample, "sample", 0, "Pick this many values from range without repeats.")
+	flag.BoolVar(&shuffle, "shuffle", false, "Write every value of range in random order.")
+	flag.Int64Var(&seed, "seed", 0, "Seed for -random, -sample and -shuffle, 0 uses the current time.")
+	flag.StringVar(&alphabet, "alphabet", alphabet, "Letters, in order, used for alphabetic ranges.")
+	flag.IntVar(&chunks, "chunks", 0, "Divide range into this many parts, writing the first and last value of each.")
+	flag.IntVar(&chunkSize, "chunk-size", 0, "Divide range into parts of this many values, writing the first and last value of each.")
+	flag.IntVar(&count, "count", 0, "Write this many values evenly spaced from start to end.")
+	flag.StringVar(&geometric, "geometric", "", "Multiply each value by this ratio, from start until end is passed.")
+	flag.BoolVar(&logScale, "log", false, "With -count, space the values evenly on a logarithmic scale.")
+	flag.StringVar(&round, "round", round, "Rounding of -count, -geometric, -log and decimal values: nearest, even, down or up.")
+	flag.BoolVar(&product, "product", false, "Write every combination of values from the expressions given.")
+	flag.StringVar(&delimiter, "delimiter", delimiter, "Text joining the values of a -product combination.")
+	flag.BoolVar(&asJSON, "json", false, "Write -product combinations as JSON arrays.")
+	flag.StringVar(&order, "order", "", "Expressions of -product from slowest to fastest changing (e.g. 2,1).")
+	flag.StringVar(&index, "index", "", "Write only the value at this position (counting from 0).")
+	flag.IntVar(&base, "base", 0, "Read and write integers in this base, from 2 to 36.")
+	flag.BoolVar(&prefix, "prefix", false, "Write 0b, 0o or 0x before binary, octal and hexadecimal values.")
+	flag.StringVar(&letterCase, "case", "", "Case of letters in -base and -roman values, upper or lower.")
+	flag.BoolVar(&roman, "roman", false, "Read and write integers as Roman numerals.")
+	flag.StringVar(&source, "source", "", "Range over the lines or JSON array or object read, lines or json.")
+	flag.StringVar(&input, "input", "", "File read by -source, standard input when not given.")
+	flag.BoolVar(&showValues, "values", false, "Write the lines or JSON values of -source rather than their positions.")
+	flag.IntVar(&limit, "limit", 0, "Stop after writing this many values.")
+	flag.BoolVar(&untilClosed, "until-stdin-closes", false, "Stop when standard input is closed.")
+	flag.StringVar(&setOperation, "set", "", "Combine sets: union, intersection, difference or complement.")
+	flag.BoolVar(&compact, "compact", false, "Write -set results as merged ranges, e.g. 1-40,45-99.")
+	flag.StringVar(&worker, "worker", "", "Write the part I/N (e.g. 2/4) of range for a worker in a job array.")
 }
 
 func assertOk(e error, failMsg string) {
 	if e != nil {
+		out.Flush()
 		fmt.Fprintf(os.Stderr, " %s\n %s\n", failMsg, e)
 		os.Exit(1)
 	}
 }
 
-func inRange(i, start, end int) bool {
-	if start <= end && i <= end {
-		return true
+// formatValue renders the value at index k of the range. v is the
+// value and s its plain text.
+func formatValue(k interface{}, v interface{}, s string) string {
+	switch {
+	case tmpl != nil:
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, map[string]interface{}{
+			"Value": s,
+			"Raw":   v,
+			"Index": k,
+		})
+		assertOk(err, "Can't apply the -format template.")
+		return buf.String()
+	case format != "":
+		if values, ok := v.([]interface{}); ok == true {
+			return fmt.Sprintf(format, values...)
+		}
+		return fmt.Sprintf(format, v)
+	}
+	return s
+}
+
+// emit writes text to standard output. When the reader has gone away
+// (a closed pipe) range stops without complaint.
+func emit(text ...string) {
+	for _, s := range text {
+		if _, err := out.WriteString(s); err != nil {
+			if errors.Is(err, syscall.EPIPE) == true {
+				os.Exit(0)
+			}
+			assertOk(err, "Can't write output.")
+		}
 	}
-	if start >= end && i >= end {
-		return true
+}
+
+// stopping reports if output should end before the k-th value for
+// -limit or -until-stdin-closes
+func stopping(k int) bool {
+	return (limit > 0 && k >= limit) || atomic.LoadInt32(&stdinClosed) == 1
+}
+
+// writeValue writes the k-th value of the output with its separator,
+// range ends here once -limit or -until-stdin-closes says to stop.
+func writeValue(k int, s string) {
+	if stopping(k) == true {
+		finish()
+		os.Exit(0)
+	}
+	if k > 0 {
+		emit(separator)
+	}
+	emit(s)
+}
+
+// eachIndex calls fn with the output position k and index i of each
+// value written from a range of n values. With -random, -sample or
+// -shuffle the indexes are chosen at random without listing the range.
+func eachIndex(n *big.Int, fn func(k int, i *big.Int)) error {
+	switch {
+	case n.Sign() == 0:
+		// An empty range has nothing to pick
+	case randomElement == true:
+		fn(0, new(big.Int).Rand(rng, n))
+	case (shuffle == true || sample > 0) && n.IsUint64() == true:
+		p := ranges.NewPermutation(n.Uint64(), rng)
+		count := n.Uint64()
+		if sample > 0 && uint64(sample) < count {
+			count = uint64(sample)
+		}
+		for k := uint64(0); k < count; k++ {
+			fn(int(k), new(big.Int).SetUint64(p.At(k)))
+		}
+	case sample > 0:
+		// More values than a permutation can order
+		seen := map[string]bool{}
+		for k := 0; k < sample; {
+			i := new(big.Int).Rand(rng, n)
+			if seen[i.String()] == false {
+				seen[i.String()] = true
+				fn(k, i)
+				k++
+			}
+		}
+	case shuffle == true:
+		return fmt.Errorf("%s values are too many to shuffle", n)
+	default:
+		k, one := 0, big.NewInt(1)
+		for i := new(big.Int); i.Cmp(n) < 0; i.Add(i, one) {
+			fn(k, new(big.Int).Set(i))
+			k++
+		}
+	}
+	return nil
+}
+
+// writeSequence writes the values of seq. Values are computed from
+// their index so random picks don't need the range in memory.
+func writeSequence(seq ranges.Sequence) error {
+	if index != "" {
+		if seq.Len().Sign() == 0 {
+			return fmt.Errorf("-index %s is out of range, the range is empty", index)
+		}
+		i, ok := new(big.Int).SetString(index, 10)
+		if ok == false || i.Sign() < 0 || i.Cmp(seq.Len()) >= 0 {
+			return fmt.Errorf("-index %s must be from 0 to %s", index, new(big.Int).Sub(seq.Len(), big.NewInt(1)))
+		}
+		writeValue(0, formatValue(i, seq.Raw(i), seq.At(i)))
+		return nil
+	}
+	if randomElement == false && shuffle == false && sample == 0 {
+		k := 0
+		for it := ranges.NewIterator(seq); it.Next(); k++ {
+			writeValue(k, formatValue(it.Index(), it.Raw(), it.Value()))
+		}
+		return nil
+	}
+	return eachIndex(seq.Len(), func(k int, i *big.Int) {
+		writeValue(k, formatValue(i, seq.Raw(i), seq.At(i)))
+	})
+}
+
+// progression returns the -count, -geometric or -log sequence from
+// start to end
+func progression(startS, endS string) (ranges.Sequence, error) {
+	first, ok := new(big.Rat).SetString(startS)
+	if ok == false {
+		return nil, fmt.Errorf("%q is not a number", startS)
+	}
+	last, ok := new(big.Rat).SetString(endS)
+	if ok == false {
+		return nil, fmt.Errorf("%q is not a number", endS)
+	}
+	places := ranges.DecimalPlaces(startS)
+	if n := ranges.DecimalPlaces(endS); n > places {
+		places = n
+	}
+	build := func(places int) (ranges.Sequence, error) {
+		switch {
+		case geometric != "":
+			ratio, ok := new(big.Rat).SetString(geometric)
+			if ok == false {
+				return nil, fmt.Errorf("ratio %q is not a number", geometric)
+			}
+			return ranges.NewGeometric(first, last, ratio, places, rounding)
+		case logScale == true:
+			return ranges.NewLogarithmic(first, last, int64(count), places, rounding)
+		}
+		return ranges.NewLinear(first, last, int64(count), places, rounding)
+	}
+	if precision >= 0 {
+		return build(precision)
+	}
+	return build(spacedPlaces(build, places))
+}
+
+// spacedPlaces returns the decimal places a -count, -geometric or -log
+// range needs so its closest values (found at one end) don't round to
+// the same value. An exact -count step is written in full, otherwise
+// two significant digits of the gap are kept.
+func spacedPlaces(build func(int) (ranges.Sequence, error), places int) int {
+	seq, err := build(places + 40)
+	if err != nil || seq.Len().Cmp(big.NewInt(2)) < 0 {
+		return places
+	}
+	at := func(i *big.Int) *big.Rat {
+		v, _ := new(big.Rat).SetString(seq.At(i))
+		return v
+	}
+	one, last := big.NewInt(1), new(big.Int).Sub(seq.Len(), big.NewInt(1))
+	gap := new(big.Rat).Sub(at(one), at(big.NewInt(0)))
+	gap.Abs(gap)
+	if g := new(big.Rat).Sub(at(last), at(new(big.Int).Sub(last, one))); g.Abs(g).Cmp(gap) < 0 {
+		gap = g
+	}
+	ten := big.NewRat(10, 1)
+	scaled := new(big.Rat).Set(gap)
+	for i := 0; i < places; i++ {
+		scaled.Mul(scaled, ten)
+	}
+	if gap.Sign() == 0 || scaled.Cmp(big.NewRat(1, 1)) >= 0 {
+		return places
+	}
+	linear := geometric == "" && logScale == false
+	for {
+		places++
+		scaled.Mul(scaled, ten)
+		if (linear == true && scaled.IsInt() == true) || scaled.Cmp(ten) >= 0 {
+			return places
+		}
+	}
+}
+
+// notation returns the notation of the range from -base, -roman,
+// -prefix and -case or detected from start and end. ok is false for
+// ranges that aren't written in a notation.
+func notation(startS, endS string) (ranges.Notation, bool, error) {
+	n, ok := ranges.Notation{Base: 10}, false
+	a, okA := ranges.DetectNotation(startS)
+	b, okB := ranges.DetectNotation(endS)
+	switch {
+	case base != 0 || roman == true:
+		if base != 0 {
+			n.Base = base
+		}
+		// Roman numerals are upper case unless written in lower case
+		n.Roman, n.Upper, ok = roman, roman == true && strings.ToUpper(startS+endS) == startS+endS, true
+	case okA == true && okB == true && a.Base == b.Base:
+		n, ok = a, true
+		n.Upper = a.Upper || b.Upper
+	}
+	if prefix == true {
+		n.Prefix, ok = true, true
+	}
+	switch letterCase {
+	case "upper":
+		n.Upper = true
+	case "lower":
+		n.Upper = false
+	case "":
+	default:
+		return n, false, fmt.Errorf("-case %q must be upper or lower", letterCase)
+	}
+	return n, ok, nil
+}
+
+// exprNotation returns the notation set by -base or -roman for range
+// expressions, nil when integers are read as written
+func exprNotation() (*ranges.Notation, error) {
+	if base == 0 && roman == false {
+		return nil, nil
+	}
+	n, _, err := notation("", "")
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// writeChunks writes the first and last value of each part of seq
+// for -chunks, -chunk-size or -worker, one part per line.
+func writeChunks(seq ranges.Sequence) error {
+	k := 0
+	pair := func(first, last *big.Int) error {
+		if stopping(k) == true {
+			finish()
+			os.Exit(0)
+		}
+		emit(formatValue(first, seq.Raw(first), seq.At(first)), " ")
+		emit(formatValue(last, seq.Raw(last), seq.At(last)), "\n")
+		k++
+		return nil
+	}
+	switch {
+	case worker != "":
+		i, n, err := parseWorker(worker)
+		if err != nil {
+			return err
+		}
+		if first, last, ok := ranges.Part(seq, i-1, n); ok == true {
+			pair(first, last)
+		}
+	case chunks > 0:
+		for i := int64(0); i < int64(chunks); i++ {
+			if first, last, ok := ranges.Part(seq, i, int64(chunks)); ok == true {
+				pair(first, last)
+			}
+		}
+	default:
+		return ranges.EachChunk(seq, int64(chunkSize), pair)
+	}
+	return nil
+}
+
+// parseWorker reads a -worker value, I/N with I from 1 to N
+func parseWorker(s string) (int64, int64, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) == 2 {
+		i, errI := strconv.ParseInt(parts[0], 10, 64)
+		n, errN := strconv.ParseInt(parts[1], 10, 64)
+		if errI == nil && errN == nil && i >= 1 && i <= n {
+			return i, n, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("-worker %q must be I/N with I from 1 to N", s)
+}
+
+// jsonTuples writes the combinations of a product as JSON arrays
+type jsonTuples struct {
+	*ranges.Product
+}
+
+// At returns combination i as a JSON array, numbers are written as
+// numbers unless JSON can't hold them as written (e.g. "01")
+func (p jsonTuples) At(i *big.Int) string {
+	values := []interface{}{}
+	for _, v := range p.Tuple(i) {
+		if _, err := strconv.ParseFloat(v, 64); err == nil && json.Valid([]byte(v)) == true {
+			values = append(values, json.Number(v))
+		} else {
+			values = append(values, v)
+		}
+	}
+	src, _ := json.Marshal(values)
+	return string(src)
+}
+
+// productRange writes the cartesian product of range expressions
+func productRange(specs []string) error {
+	n, err := exprNotation()
+	if err != nil {
+		return err
+	}
+	seqs := []ranges.Sequence{}
+	for _, spec := range specs {
+		seq, err := ranges.SpecNotation(spec, n)
+		if err != nil {
+			return fmt.Errorf("%q, %s", spec, err)
+		}
+		seqs = append(seqs, seq)
+	}
+	positions := []int{}
+	if order != "" {
+		for _, s := range strings.Split(order, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("-order %q must be expression positions, e.g. 2,1", order)
+			}
+			positions = append(positions, n)
+		}
+	}
+	p, err := ranges.NewProduct(seqs, positions, delimiter)
+	if err != nil {
+		return err
+	}
+	if asJSON == true {
+		return writeSequence(jsonTuples{p})
+	}
+	return writeSequence(p)
+}
+
+// sourceRange writes the line numbers, JSON indexes or keys (or
+// values) read by -source sliced by args
+func sourceRange(args []string) error {
+	in := os.Stdin
+	if input != "" && input != "-" {
+		fp, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+		in = fp
+	}
+	var (
+		positions, values ranges.List
+		err               error
+	)
+	switch source {
+	case "lines":
+		positions, values, err = ranges.ReadLines(in)
+	case "json":
+		positions, values, err = ranges.ReadJSON(in)
+	default:
+		err = fmt.Errorf("-source %q must be lines or json", source)
+	}
+	if err != nil {
+		return err
+	}
+	seq := positions
+	if showValues == true {
+		seq = values
+	}
+	if len(seq) == 0 {
+		return nil
+	}
+	// START, END and INCREMENT default to the whole range
+	bounds := []string{"", "", increment}
+	copy(bounds, args)
+	slice := []int{0, -1, 1}
+	for i, arg := range bounds {
+		if arg == "" {
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", arg)
+		}
+		// Lines are numbered from 1
+		if source == "lines" && i < 2 {
+			if n == 0 || n > len(seq) || -n > len(seq) {
+				return fmt.Errorf("line %d is outside the %d lines, lines are numbered from 1", n, len(seq))
+			}
+			if n > 0 {
+				n--
+			}
+		}
+		slice[i] = n
+	}
+	sliced, err := ranges.Slice(seq, slice[0], slice[1], slice[2])
+	if err != nil {
+		return err
+	}
+	return writeSequence(sliced)
+}
+
+// operand returns the integers of a range expression, @FILE or -
+// (standard input) holding one integer per line
+func operand(arg string) (*ranges.Set, error) {
+	if arg != "-" && strings.HasPrefix(arg, "@") == false {
+		n, err := exprNotation()
+		if err != nil {
+			return nil, err
+		}
+		e, err := ranges.ParseNotation(arg, n)
+		if err != nil {
+			return nil, err
+		}
+		return e.Set()
+	}
+	in := os.Stdin
+	if arg != "-" {
+		fp, err := os.Open(arg[1:])
+		if err != nil {
+			return nil, err
+		}
+		defer fp.Close()
+		in = fp
+	}
+	_, lines, err := ranges.ReadLines(in)
+	if err != nil {
+		return nil, err
+	}
+	values := ranges.List{}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return ranges.SetOf(values)
+}
+
+// setRange writes the result of a -set operation on args
+func setRange(args []string) error {
+	switch setOperation {
+	case "union", "intersection", "difference", "complement":
+	default:
+		return fmt.Errorf("-set %q must be union, intersection, difference or complement", setOperation)
+	}
+	sets := []*ranges.Set{}
+	for _, arg := range args {
+		s, err := operand(arg)
+		if err != nil {
+			return fmt.Errorf("%q, %s", arg, err)
+		}
+		sets = append(sets, s)
+	}
+	result := sets[0]
+	for _, s := range sets[1:] {
+		switch setOperation {
+		case "union", "complement":
+			result = result.Union(s)
+		case "intersection":
+			result = result.Intersect(s)
+		case "difference":
+			result = result.Difference(s)
+		}
+	}
+	if setOperation == "complement" {
+		result = result.Gaps()
+	}
+	if compact == true {
+		writeValue(0, result.String())
+		return nil
+	}
+	return writeSequence(result)
+}
+
+// expressionRange writes the values of a range expression or brace
+// template
+func expressionRange(expr string) error {
+	n, err := exprNotation()
+	if err != nil {
+		return err
+	}
+	if ranges.IsBraceTemplate(expr) == true {
+		if pad == true {
+			return fmt.Errorf("-pad can't be used with brace templates, write a leading zero (e.g. {01..10})")
+		}
+		seq, err := ranges.SpecNotation(expr, n)
+		if err != nil {
+			return err
+		}
+		return writeSequence(seq)
+	}
+	e, err := ranges.ParseNotation(expr, n)
+	if err != nil {
+		return err
+	}
+	if pad == true {
+		e.Pad()
+	}
+	if randomElement == true || sample > 0 || shuffle == true || index != "" {
+		seq, err := e.Sequence()
+		if err != nil {
+			return err
+		}
+		return writeSequence(seq)
+	}
+	k := 0
+	return e.Each(func(value string) error {
+		writeValue(k, formatValue(k, ranges.Number(value), value))
+		k++
+		return nil
+	})
+}
+
+// finish ends the output, with -newline the last line is terminated,
+// and writes what is left in the buffer
+func finish() {
+	if newline == true {
+		emit("\n")
+	}
+	if err := out.Flush(); err != nil {
+		if errors.Is(err, syscall.EPIPE) == true {
+			os.Exit(0)
+		}
+		assertOk(err, "Can't write output.")
 	}
-	return false
 }
 
 func main() {
@@ -133,9 +960,9 @@ func main() {
 	flag.Parse()
 	// Configuration and command line interation
 	cfg := cli.New(appName, appName, fmt.Sprintf(shelltools.LicenseText, appName, shelltools.Version), shelltools.Version)
-	cfg.UsageText = fmt.Sprintf(usage, appName)
-	cfg.DescriptionText = fmt.Sprintf(description, appName)
-	cfg.ExampleText = fmt.Sprintf(examples, appName, appName, appName, appName, appName)
+	cfg.UsageText = fmt.Sprintf(usage, appName, appName, appName, appName, appName)
+	cfg.DescriptionText = fmt.Sprintf(description, appName, appName)
+	cfg.ExampleText = fmt.Sprintf(examples, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName)
 
 	if showHelp == true {
 		fmt.Println(cfg.Usage())
@@ -154,61 +981,141 @@ func main() {
 
 	argc := flag.NArg()
 	argv := flag.Args()
+	var err error
 
-	if argc < 2 {
-		fmt.Fprintf(os.Stderr, "Must include start and end integers.")
+	// Start and end may be given by -start and -end
+	if argc == 0 && start != "" && end != "" {
+		argv, argc = []string{start, end}, 2
+	}
+	if argc == 0 && source == "" {
+		fmt.Fprintf(os.Stderr, "Must include start and end values or an expression.")
 		os.Exit(1)
-	} else if argc > 3 {
+	} else if argc > 3 && product == false && setOperation == "" {
 		fmt.Fprintf(os.Stderr, "Too many command line arguments.")
 		os.Exit(1)
 	}
+	if argc == 3 && product == false && source == "" && setOperation == "" {
+		increment = argv[2]
+	}
+
+	if limit < 0 {
+		assertOk(fmt.Errorf("-limit %d", limit), "Limit can't be negative.")
+	}
+	if untilClosed == true {
+		if source != "" && (input == "" || input == "-") {
+			assertOk(fmt.Errorf("-source reads standard input"), "Use -input with -source and -until-stdin-closes.")
+		}
+		// Read standard input until it is closed
+		go func() {
+			io.Copy(io.Discard, os.Stdin)
+			atomic.StoreInt32(&stdinClosed, 1)
+		}()
+	}
+	// A closed pipe is reported as an error from writes rather than
+	// ending range with SIGPIPE
+	signal.Ignore(syscall.SIGPIPE)
 
-	start, err := strconv.Atoi(argv[0])
-	assertOk(err, "Start value must be an integer.")
-	end, err := strconv.Atoi(argv[1])
-	assertOk(err, "End value must be an integer.")
-	if argc == 3 {
-		increment, err = strconv.Atoi(argv[2])
-	} else if increment == 0 {
-		err = errors.New("increment was zero")
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng = rand.New(rand.NewSource(seed))
+	if sample < 0 {
+		assertOk(fmt.Errorf("-sample %d", sample), "Sample size can't be negative.")
 	}
-	assertOk(err, "Increment must be a non-zero integer.")
 
-	if start == end {
-		fmt.Printf("%d", start)
+	if strings.Contains(format, "{{") {
+		tmpl, err = template.New("format").Parse(format)
+		assertOk(err, "Format must be a printf format or a Go template.")
+	}
+	separator = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(separator)
+	delimiter = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(delimiter)
+	if newline == true {
+		separator = "\n"
+	}
+
+	if product == true {
+		assertOk(productRange(argv), "Each argument must be a range expression or brace template.")
+		finish()
+		os.Exit(0)
+	}
+	if source != "" {
+		assertOk(sourceRange(argv), "Can't range over -source.")
+		finish()
+		os.Exit(0)
+	}
+	if setOperation != "" {
+		assertOk(setRange(argv), "Can't combine sets.")
+		finish()
 		os.Exit(0)
 	}
+	if asJSON == true || order != "" {
+		assertOk(fmt.Errorf("-json or -order without -product"), "Use -json and -order with -product.")
+	}
+
+	rounding, err = ranges.ParseRounding(round)
+	assertOk(err, "Round must be nearest, even, down or up.")
+	spaced := count != 0 || geometric != "" || logScale == true
+	switch {
+	case count < 0:
+		assertOk(fmt.Errorf("-count %d", count), "Count must be positive.")
+	case logScale == true && count == 0:
+		assertOk(fmt.Errorf("-log needs -count"), "Use -count with -log.")
+	case geometric != "" && count != 0:
+		assertOk(fmt.Errorf("-geometric with -count"), "Use -log rather than -geometric with -count.")
+	case spaced == true && argc != 2:
+		assertOk(fmt.Errorf("%d arguments", argc), "-count, -geometric and -log need START and END.")
+	}
 
-	// Normalize to a positive value.
-	if start <= end && increment < 0 {
-		increment = increment * -1
+	chunked := chunks != 0 || chunkSize != 0 || worker != ""
+	if chunks < 0 || chunkSize < 0 {
+		assertOk(fmt.Errorf("-chunks %d, -chunk-size %d", chunks, chunkSize), "Chunks and chunk size must be positive.")
+	}
+	if (chunks != 0 && chunkSize != 0) || (worker != "" && (chunks != 0 || chunkSize != 0)) {
+		assertOk(fmt.Errorf("more than one way to divide range"), "Use only one of -chunks, -chunk-size and -worker.")
 	}
-	if start > end && increment > 0 {
-		increment = increment * -1
+	if chunked == true && (argc == 1 || randomElement == true || sample > 0 || shuffle == true) {
+		assertOk(fmt.Errorf("chunks need START and END"), "Chunks can't be used with an expression, -random, -sample or -shuffle.")
 	}
 
-	// If randonElement than generate range and pick the ith random element from range
-	var (
-		ithArray []int
-		ith      = 0
-	)
+	if argc == 1 {
+		assertOk(expressionRange(argv[0]), "Expression must be a range expression or brace template.")
+		finish()
+		os.Exit(0)
+	}
 
-	// Now count up or down as appropriate.
-	for i := start; inRange(i, start, end) == true; i = i + increment {
-		if randomElement == true {
-			ithArray = append(ithArray, i)
+	var seq ranges.Sequence
+	if spaced == true {
+		seq, err = progression(argv[0], argv[1])
+		assertOk(err, "Start, end and ratio must be numbers.")
+	} else {
+		n, ok, err := notation(argv[0], argv[1])
+		assertOk(err, "Case must be upper or lower.")
+		if ok == true {
+			seq, err = ranges.NewNumeral(argv[0], argv[1], increment, n)
+			assertOk(err, "Start, end and increment must be integers in the base or Roman numerals.")
 		} else {
-			if i == start {
-				fmt.Printf("%d", i)
-			} else {
-				fmt.Printf(" %d", i)
-			}
+			seq, err = ranges.New(argv[0], argv[1], increment, alphabet)
+			assertOk(err, "Start, end and increment must be numbers or letters.")
+		}
+	}
+	if d, ok := seq.(*ranges.Decimal); ok == true {
+		if precision >= 0 {
+			d.Places = precision
 		}
+		d.Rounding = rounding
 	}
-	// if randomElement we should an array we can pick the elements from
-	if randomElement == true {
-		rand.Seed(time.Now().Unix())
-		ith = rand.Intn(len(ithArray))
-		fmt.Printf("%d", ithArray[ith])
+	if r, ok := seq.(*ranges.Numeral); ok == true && r.Notation.Roman == true {
+		pad = false
+	}
+	if _, ok := seq.(*ranges.Alpha); pad == true && ok == false {
+		seq = ranges.Padded(seq, ranges.Widest(seq))
+	}
+	if chunked == true {
+		assertOk(writeChunks(seq), "Can't divide range.")
+		newline = false
+		finish()
+		os.Exit(0)
 	}
+	assertOk(writeSequence(seq), "Can't write range.")
+	finish()
 }
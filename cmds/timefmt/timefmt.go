@@ -19,10 +19,14 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,7 +37,8 @@ import (
 )
 
 var (
-	usage = `USAGE: %s [OPTIONS] TIME_STRING_TO_CONVERT`
+	usage = `USAGE: %s [OPTIONS] TIME_STRING_TO_CONVERT
+       %s translate [-from DIALECT] [-to DIALECT] PATTERN`
 
 	description = `
 SYNOPSIS
@@ -48,6 +53,91 @@ For details see https://golang.org/pkg/time/#Time.Format.
 One additional time layout provided by %s 
  
 + mysql "2006-01-02 15:04:05 -0700" 
+
+Spreadsheet serial dates and Julian Day numbers can be used as input
+or output formats by name
+
++ excel1900, Excel/LibreOffice serial date, 1 is 1900-01-01 (keeps
+  Excel's 1900 leap year bug)
++ excel1904, Excel for Mac serial date, 0 is 1904-01-01
++ jd, Julian Day
++ mjd, Modified Julian Day
+
+Using "edtf" as the input format validates Extended Date/Time Format
+(EDTF Levels 0 - 2) strings like "1984?", "198X", "2004-06~-11" or
+"1964/2008" and converts them to the earliest and latest time they
+cover, written as EARLIEST/LATEST in the output format. Open ends
+are written as ".." and unknown ends are left empty.
+
+Dates with reduced precision like "2016" or "2016-07" are accepted
+when they don't match the input format. Only their known components
+are written (e.g. "2016-07" formatted as RFC3339 is "2016-07"). Use
+-expand start or -expand end to convert them to their first or last
+instant instead.
+
+With -columns %s reads CSV (or TSV with -tsv) from standard input
+and converts the time strings found in the listed columns, writing
+the records to standard output. Columns are numbered from 1 or named
+by the header row. Other fields and quoting are left as they are.
+Rows that can't be converted are handled based on -on-error,
+
++ skip, leave the row out
++ blank, empty the field
++ keep, leave the original value
++ abort, stop with an error (default)
+
+Month and weekday names, full or abbreviated, can be written and read
+in another language with -locale. Spanish (es), French (fr) and
+German (de) are available along with English (en).
+
+Times can be truncated with -truncate or rounded with -round to a
+second, minute, hour, day, week, month or year before they are
+formatted. This is done in the time's own time zone. Weeks start on
+Monday unless -week-start names another day.
+
+With -json the calendar fields of each time (year, quarter, month,
+day, day of year, weekday, ISO year and week, hour, minute, second,
+zone, epoch, etc.) are written as a JSON object. When more than one
+time is given the objects are written as a JSON array.
+
+With -normalize the date phrases used in catalog records (AACR2 and
+RDA conventions) are converted to EDTF, one per line, from the
+command line or standard input. For example "circa 1850" is "1850~",
+"ca. 1920s" is "192X~", "[1875?]" is "1875?", "18--" is "18XX",
+"early 19th century" is "1800/1832" and "Spring 1962" is "1962-21".
+Add -bounds to write the earliest and latest times in the output
+format instead. Values that can't be normalized leave an empty line
+and are listed (line number, value and reason) on standard error or
+in the file named by -rejects for review.
+
+With -check the values on the command line, or one per line from
+standard input, are checked strictly against the input format
+(reduced precision dates are failures). Each value that fails is
+reported on standard output with its line number, the value and the
+parser error. Values that parse but look wrong are reported as
+suspicious, for example a weekday that doesn't match the date (Go
+ignores it), an unknown time zone abbreviation (Go gives it a zero
+offset) or a year outside the -years window. The exit status is 1 if
+any value failed, 2 if some were only suspicious.
+
+Fractional seconds are read whatever their number of digits. Go
+can't represent a leap second like "23:59:60", -leap-second says how
+to read one,
+
++ error, reject it (default)
++ clamp, use the last nanosecond of 23:59:59
++ smear, spread the leap second over the last 1000 seconds of the
+  day (UTC-SLS) adjusting the other times in that window too
+
+Use -precision to set the number of fractional second digits written
+(0 - 9). Extra digits are truncated.
+
+The translate subcommand converts a date pattern between Go layouts
+(go), strftime (C, Python, Ruby), Java's SimpleDateFormat and ICU
+(java) and moment.js (moment). The default is from strftime to go.
+The translated pattern is written to standard output. Anything that
+can't be translated exactly, like a week number in a Go layout, is
+described in a warning on standard error.
 `
 
 	examples = `
@@ -60,6 +150,73 @@ Yields "07/02/2016"
     %s -input mysql -output RFC822  "2016-07-02 08:08:08"
 
 Yields "02 Jul 16 08:08 UTC"
+
+    %s -input edtf -output "2006-01-02" "1984?"
+
+Yields "1984-01-01/1984-12-31"
+
+    %s -input edtf -level "[1667,1668,1670..1672]"
+
+Yields "2"
+
+    %s -expand end "2016-02"
+
+Yields "2016-02-29T23:59:59Z"
+
+    cat export.csv | %s -columns "created,updated" -input mysql -on-error keep
+
+Converts the created and updated columns of export.csv from MySQL
+timestamps to RFC3339
+
+    %s -locale es -input "2006-01-02" -output "2 de January de 2006" "2016-07-03"
+
+Yields "3 de julio de 2016"
+
+    %s -locale fr -input "January 2006" -output "2006-01" "juillet 2016"
+
+Yields "2016-07"
+
+    %s -truncate hour "2016-07-02T08:48:08-07:00"
+
+Yields "2016-07-02T08:00:00-07:00"
+
+    %s -round day -output "2006-01-02" "2016-07-02T18:08:08-07:00"
+
+Yields "2016-07-03"
+
+    %s -json "2016-07-02T08:08:08Z" | jq .iso_week
+
+Yields 26
+
+    %s -input excel1900 -output RFC3339 "42553.338981"
+
+Yields "2016-07-02T08:08:08Z"
+
+    %s -output mjd "2016-07-02T12:00:00Z"
+
+Yields "57571.5"
+
+    %s -leap-second clamp -precision 3 "2016-12-31T23:59:60.5Z"
+
+Yields "2016-12-31T23:59:59.999Z"
+
+    cat dates.txt | %s -normalize -rejects review.tsv > dates-edtf.txt
+
+Normalizes the dates in dates.txt listing the ones needing review in
+review.tsv
+
+    cat dates.txt | %s -check -input "Mon, 2006-01-02" -years 1850-2030
+
+Lists the lines of dates.txt that don't match the input format, have
+the wrong weekday or years outside 1850 through 2030
+
+    %s translate -from java -to go "yyyy-MM-dd'T'HH:mm"
+
+Yields "2006-01-02T15:04"
+
+    %s translate "%%d/%%m/%%Y %%H:%%M"
+
+Yields "02/01/2006 15:04"
 `
 
 	// Standard Options
@@ -71,6 +228,33 @@ Yields "02 Jul 16 08:08 UTC"
 	useUTC       bool
 	inputFormat  = time.RFC3339
 	outputFormat = time.RFC3339
+	showEarliest bool
+	showLatest   bool
+	showLevel    bool
+	expandTo     string
+	columnList   string
+	delimiter    = ","
+	useTSV       bool
+	useHeader    bool
+	onError      = "abort"
+	localeName   string
+	locale       *timefmt.Locale
+	truncateTo   string
+	roundTo      string
+	weekStart    = "monday"
+	adjustUnit   timefmt.Unit
+	startOfWeek  time.Weekday
+	asJSON       bool
+	normalize    bool
+	showBounds   bool
+	rejectsFile  string
+	checkValues  bool
+	yearWindow   string
+	firstYear    int
+	lastYear     int
+	leapSecond   = "error"
+	leapPolicy   timefmt.LeapPolicy
+	precision    = -1
 )
 
 func init() {
@@ -81,6 +265,27 @@ func init() {
 	flag.BoolVar(&useUTC, "utc", false, "timestamps in UTC")
 	flag.StringVar(&inputFormat, "input", inputFormat, "Set format for input")
 	flag.StringVar(&outputFormat, "output", outputFormat, "Set format for output")
+	flag.BoolVar(&showEarliest, "earliest", false, "with edtf input, only display the earliest time")
+	flag.BoolVar(&showLatest, "latest", false, "with edtf input, only display the latest time")
+	flag.BoolVar(&showLevel, "level", false, "with edtf input, display the EDTF level (0 - 2)")
+	flag.StringVar(&expandTo, "expand", "", "expand a partial date to its start or end")
+	flag.StringVar(&columnList, "columns", "", "read CSV from stdin converting these columns (numbers or header names, comma separated)")
+	flag.StringVar(&delimiter, "delimiter", delimiter, "field delimiter used with -columns")
+	flag.BoolVar(&useTSV, "tsv", false, "read tab separated values with -columns")
+	flag.BoolVar(&useHeader, "header", false, "first row is a header with -columns")
+	flag.StringVar(&truncateTo, "truncate", "", "truncate the time to a unit (second, minute, hour, day, week, month, year)")
+	flag.StringVar(&roundTo, "round", "", "round the time to the nearest unit (second, minute, hour, day, week, month, year)")
+	flag.StringVar(&weekStart, "week-start", weekStart, "day weeks start on for -truncate and -round")
+	flag.BoolVar(&asJSON, "json", false, "display the calendar fields of each time as a JSON object")
+	flag.BoolVar(&normalize, "normalize", false, "normalize catalog date phrases (e.g. \"ca. 1920s\") to EDTF, reads stdin without arguments")
+	flag.BoolVar(&showBounds, "bounds", false, "with -normalize, display the earliest and latest times instead of EDTF")
+	flag.StringVar(&rejectsFile, "rejects", "", "with -normalize, write values that can't be normalized to this file instead of stderr")
+	flag.BoolVar(&checkValues, "check", false, "check values match the input format, reporting failures and suspicious values, reads stdin without arguments")
+	flag.StringVar(&yearWindow, "years", "", "with -check, flag years outside FIRST-LAST (e.g. 1850-2030)")
+	flag.StringVar(&leapSecond, "leap-second", leapSecond, "how to read a leap second like 23:59:60 (clamp, smear, error)")
+	flag.IntVar(&precision, "precision", precision, "number of fractional second digits to output (0 - 9), -1 uses the output format")
+	flag.StringVar(&localeName, "locale", "", "use month and weekday names from locale (e.g. es, fr, de)")
+	flag.StringVar(&onError, "on-error", onError, "with -columns, what to do with rows that can't be converted (skip, blank, keep, abort)")
 }
 
 func applyConstants(s string) string {
@@ -121,6 +326,408 @@ func applyConstants(s string) string {
 	return s
 }
 
+// formatTime renders t using layout and the month and weekday names
+// of the selected locale.
+func formatTime(t time.Time, layout string) string {
+	if timefmt.IsSerialLayout(layout) {
+		return timefmt.FormatSerial(t, layout)
+	}
+	if precision >= 0 {
+		layout = timefmt.SetPrecision(layout, precision)
+	}
+	if locale != nil {
+		return locale.Format(t, layout)
+	}
+	return t.Format(layout)
+}
+
+// adjustTime applies -truncate or -round to t
+func adjustTime(t time.Time) time.Time {
+	switch {
+	case truncateTo != "":
+		return timefmt.Truncate(t, adjustUnit, startOfWeek)
+	case roundTo != "":
+		return timefmt.Round(t, adjustUnit, startOfWeek)
+	}
+	return t
+}
+
+// formatEDTF renders the earliest and latest times of an EDTF value
+// using the output format.
+func formatEDTF(e *timefmt.EDTF, layout string) string {
+	if showLevel == true {
+		return fmt.Sprintf("%d", e.Level)
+	}
+	earliest, latest := "", ""
+	switch {
+	case e.OpenStart:
+		earliest = ".."
+	case e.UnknownStart == false:
+		earliest = formatTime(e.Earliest, layout)
+	}
+	switch {
+	case e.OpenEnd:
+		latest = ".."
+	case e.UnknownEnd == false:
+		latest = formatTime(e.Latest, layout)
+	}
+	if showEarliest == true && showLatest == false {
+		return earliest
+	}
+	if showLatest == true && showEarliest == false {
+		return latest
+	}
+	return earliest + "/" + latest
+}
+
+// convert parses a single time string using the input format and
+// renders it using the output format.
+func convert(dt string) (string, error) {
+	if strings.ToLower(inputFormat) == "edtf" {
+		e, err := timefmt.ParseEDTF(dt)
+		if err != nil {
+			return "", err
+		}
+		return formatEDTF(e, outputFormat), nil
+	}
+	if timefmt.IsSerialLayout(inputFormat) {
+		t, err := timefmt.ParseSerial(inputFormat, dt)
+		if err != nil {
+			return "", err
+		}
+		return formatTime(adjustTime(t), outputFormat), nil
+	}
+	if locale != nil {
+		dt = locale.Translate(inputFormat, dt)
+	}
+	inputDate, leapErr := timefmt.ParseWithLeap(inputFormat, dt, leapPolicy)
+	if expandTo == "" && leapErr == nil {
+		return formatTime(adjustTime(inputDate), outputFormat), nil
+	}
+	partialDate, err := timefmt.ParsePartial(inputFormat, dt)
+	if err != nil {
+		// Report why a leap second was rejected
+		if leapErr != nil {
+			return "", leapErr
+		}
+		return "", err
+	}
+	switch expandTo {
+	case "start":
+		return formatTime(adjustTime(partialDate.Start()), outputFormat), nil
+	case "end":
+		return formatTime(adjustTime(partialDate.End()), outputFormat), nil
+	}
+	if truncateTo != "" || roundTo != "" || timefmt.IsSerialLayout(outputFormat) {
+		return formatTime(adjustTime(partialDate.Start()), outputFormat), nil
+	}
+	return formatTime(partialDate.Time, partialDate.Reduce(outputFormat)), nil
+}
+
+// parseTime parses a single time string using the input format.
+// Partial dates become their first instant, or their last with
+// -expand end.
+func parseTime(dt string) (time.Time, error) {
+	if timefmt.IsSerialLayout(inputFormat) {
+		t, err := timefmt.ParseSerial(inputFormat, dt)
+		return adjustTime(t), err
+	}
+	if locale != nil {
+		dt = locale.Translate(inputFormat, dt)
+	}
+	partialDate, err := timefmt.ParsePartial(inputFormat, dt)
+	if err != nil {
+		if t, e := timefmt.ParseWithLeap(inputFormat, dt, leapPolicy); e == nil {
+			return adjustTime(t), nil
+		}
+		return time.Time{}, err
+	}
+	if expandTo == "end" {
+		return adjustTime(partialDate.End()), nil
+	}
+	return adjustTime(partialDate.Start()), nil
+}
+
+// components returns the calendar fields of t, names follow -locale
+func components(t time.Time) *timefmt.Components {
+	c := timefmt.NewComponents(t)
+	if locale != nil {
+		c.MonthName = locale.Months[t.Month()-1]
+		c.WeekdayName = locale.Days[t.Weekday()]
+	}
+	return c
+}
+
+// splitRecord splits a CSV or TSV record into its fields, quotes are
+// kept so unchanged fields can be written back as they were read.
+func splitRecord(record, delim string) []string {
+	fields := []string{}
+	inQuote := false
+	start := 0
+	for i := 0; i < len(record); i++ {
+		switch {
+		case record[i] == '"':
+			inQuote = !inQuote
+		case inQuote == false && strings.HasPrefix(record[i:], delim):
+			fields = append(fields, record[start:i])
+			start = i + len(delim)
+			i += len(delim) - 1
+		}
+	}
+	return append(fields, record[start:])
+}
+
+// unquote returns the value of a raw field
+func unquote(field string) string {
+	if len(field) >= 2 && strings.HasPrefix(field, `"`) && strings.HasSuffix(field, `"`) {
+		return strings.Replace(field[1:len(field)-1], `""`, `"`, -1)
+	}
+	return field
+}
+
+// quote writes a value as a field, it is quoted if the original field
+// was quoted or if the value requires it.
+func quote(value, original, delim string) string {
+	if strings.HasPrefix(original, `"`) || strings.Contains(value, delim) || strings.ContainsAny(value, "\"\r\n") {
+		return `"` + strings.Replace(value, `"`, `""`, -1) + `"`
+	}
+	return value
+}
+
+// readRecord reads the next record including any line breaks inside
+// quoted fields. It returns the record and its line ending.
+func readRecord(r *bufio.Reader) (string, string, error) {
+	record, err := r.ReadString('\n')
+	for err == nil && strings.Count(record, `"`)%2 == 1 {
+		var more string
+		more, err = r.ReadString('\n')
+		record += more
+	}
+	if record == "" {
+		return "", "", err
+	}
+	eol := ""
+	if strings.HasSuffix(record, "\n") {
+		eol = "\n"
+		record = strings.TrimSuffix(record, "\n")
+		if strings.HasSuffix(record, "\r") {
+			eol = "\r\n"
+			record = strings.TrimSuffix(record, "\r")
+		}
+	}
+	return record, eol, nil
+}
+
+// resolveColumns turns a list of column numbers (starting at 1) or
+// header names into field positions.
+func resolveColumns(columnList string, header []string) ([]int, error) {
+	columns := []int{}
+	for _, col := range strings.Split(columnList, ",") {
+		col = strings.TrimSpace(col)
+		if i, err := strconv.Atoi(col); err == nil {
+			if i < 1 {
+				return nil, fmt.Errorf("column numbers start at 1, got %d", i)
+			}
+			columns = append(columns, i-1)
+			continue
+		}
+		found := false
+		for i, name := range header {
+			if unquote(name) == col {
+				columns = append(columns, i)
+				found = true
+				break
+			}
+		}
+		if found == false {
+			return nil, fmt.Errorf("can't find column %q in header", col)
+		}
+	}
+	return columns, nil
+}
+
+// convertColumns reads CSV or TSV records from in, converts the
+// time strings in the selected columns and writes the records to out.
+// Fields that are not converted are written unchanged.
+func convertColumns(in io.Reader, out io.Writer, columnList string) error {
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	var columns []int
+	for lineNo := 1; ; lineNo++ {
+		record, eol, err := readRecord(r)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if record == "" && eol == "" {
+			return nil
+		}
+		fields := splitRecord(record, delimiter)
+		if columns == nil {
+			// Names in the column list imply a header row
+			header := []string{}
+			if useHeader == true {
+				header = fields
+			}
+			columns, err = resolveColumns(columnList, header)
+			if err != nil && useHeader == false {
+				columns, err = resolveColumns(columnList, fields)
+				useHeader = true
+			}
+			if err != nil {
+				return err
+			}
+			if useHeader == true {
+				fmt.Fprintf(w, "%s%s", record, eol)
+				lineNo += strings.Count(record, "\n")
+				continue
+			}
+		}
+
+		skip := false
+		for _, col := range columns {
+			if col >= len(fields) {
+				continue
+			}
+			value := unquote(fields[col])
+			s, err := convert(value)
+			if err == nil {
+				fields[col] = quote(s, fields[col], delimiter)
+				continue
+			}
+			msg := fmt.Sprintf("line %d, column %d, can't read %s, %s", lineNo, col+1, value, err)
+			switch onError {
+			case "abort":
+				return fmt.Errorf("%s", msg)
+			case "skip":
+				skip = true
+			case "blank":
+				fields[col] = quote("", fields[col], delimiter)
+			}
+			fmt.Fprintf(os.Stderr, "%s\n", msg)
+		}
+		if skip == false {
+			fmt.Fprintf(w, "%s%s", strings.Join(fields, delimiter), eol)
+		}
+		lineNo += strings.Count(record, "\n")
+	}
+}
+
+// normalizeDates writes the EDTF (or bounds) for each catalog date
+// phrase, one per line. Values that can't be normalized leave an empty
+// line and are reported to rejects as line number, value and error
+// separated by tabs. It returns the number of rejected values.
+func normalizeDates(values []string, out io.Writer, rejects io.Writer) int {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	rejected := 0
+	for i, value := range values {
+		expr, err := timefmt.NormalizeDate(value)
+		if err == nil && showBounds == true {
+			var e *timefmt.EDTF
+			if e, err = timefmt.ParseEDTF(expr); err == nil {
+				expr = formatEDTF(e, outputFormat)
+			}
+		}
+		if err != nil {
+			rejected++
+			fmt.Fprintf(rejects, "%d\t%s\t%s\n", i+1, value, err)
+			expr = ""
+		}
+		fmt.Fprintf(w, "%s\n", expr)
+	}
+	return rejected
+}
+
+// checkYears reports times outside the -years window
+func checkYears(times ...time.Time) string {
+	if yearWindow == "" {
+		return ""
+	}
+	for _, t := range times {
+		if t.IsZero() == false && (t.Year() < firstYear || t.Year() > lastYear) {
+			return fmt.Sprintf("year %d is outside %d-%d", t.Year(), firstYear, lastYear)
+		}
+	}
+	return ""
+}
+
+// checkDates writes a line for each value that fails to parse using
+// the input format or looks suspicious. It returns the number of
+// failures and the number of suspicious values.
+func checkDates(values []string, out io.Writer) (int, int) {
+	failed, suspicious := 0, 0
+	for i, value := range values {
+		var (
+			err      error
+			problems []string
+		)
+		switch {
+		case strings.ToLower(inputFormat) == "edtf":
+			var e *timefmt.EDTF
+			if e, err = timefmt.ParseEDTF(value); err == nil {
+				problems = append(problems, checkYears(e.Earliest, e.Latest))
+			}
+		case timefmt.IsSerialLayout(inputFormat):
+			var t time.Time
+			if t, err = timefmt.ParseSerial(inputFormat, value); err == nil {
+				problems = append(problems, checkYears(t))
+			}
+		default:
+			dt := value
+			if locale != nil {
+				dt = locale.Translate(inputFormat, dt)
+			}
+			var t time.Time
+			if t, err = timefmt.ParseWithLeap(inputFormat, dt, leapPolicy); err == nil {
+				problems = append(timefmt.Suspicious(inputFormat, dt, t), checkYears(t))
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "%d\t%s\t%s\n", i+1, value, err)
+			continue
+		}
+		reasons := []string{}
+		for _, problem := range problems {
+			if problem != "" {
+				reasons = append(reasons, problem)
+			}
+		}
+		if len(reasons) > 0 {
+			suspicious++
+			fmt.Fprintf(out, "%d\t%s\tsuspicious, %s\n", i+1, value, strings.Join(reasons, "; "))
+		}
+	}
+	return failed, suspicious
+}
+
+// translate implements the translate subcommand returning the exit code
+func translate(appName string, args []string) int {
+	from, to := timefmt.StrftimeDialect, timefmt.GoDialect
+	fs := flag.NewFlagSet(appName+" translate", flag.ContinueOnError)
+	fs.StringVar(&from, "from", from, "dialect of PATTERN (go, strftime, java, moment)")
+	fs.StringVar(&to, "to", to, "dialect to translate PATTERN to (go, strftime, java, moment)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "USAGE: %s translate [-from DIALECT] [-to DIALECT] PATTERN\n", appName)
+		return 1
+	}
+	s, warnings, err := timefmt.TranslateLayout(fs.Arg(0), from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	fmt.Printf("%s\n", s)
+	return 0
+}
+
 func main() {
 	appName := path.Base(os.Args[0])
 	flag.Parse()
@@ -128,9 +735,9 @@ func main() {
 
 	// Configuration and command line interation
 	cfg := cli.New(appName, appName, fmt.Sprintf(shelltools.LicenseText, appName, shelltools.Version), shelltools.Version)
-	cfg.UsageText = fmt.Sprintf(usage, appName)
-	cfg.DescriptionText = fmt.Sprintf(description, appName, appName)
-	cfg.ExampleText = fmt.Sprintf(examples, appName, appName)
+	cfg.UsageText = fmt.Sprintf(usage, appName, appName)
+	cfg.DescriptionText = fmt.Sprintf(description, appName, appName, appName)
+	cfg.ExampleText = fmt.Sprintf(examples, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName)
 
 	if showHelp == true {
 		fmt.Println(cfg.Usage())
@@ -147,18 +754,176 @@ func main() {
 		os.Exit(0)
 	}
 
-	var (
-		inputDate time.Time
-		err       error
-	)
+	if len(args) > 0 && args[0] == "translate" {
+		os.Exit(translate(appName, args[1:]))
+	}
 
 	// Handle constants for formatting
 	inputFormat = applyConstants(inputFormat)
 	outputFormat = applyConstants(outputFormat)
 
+	if precision > 9 {
+		fmt.Fprintf(os.Stderr, "-precision must be between 0 and 9\n")
+		os.Exit(1)
+	}
+
+	if p, err := timefmt.ParseLeapPolicy(leapSecond); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	} else {
+		leapPolicy = p
+	}
+
+	if localeName != "" {
+		var err error
+		locale, err = timefmt.LookupLocale(localeName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if truncateTo != "" || roundTo != "" {
+		var err error
+		if truncateTo != "" && roundTo != "" {
+			err = fmt.Errorf("use either -truncate or -round, not both")
+		} else if truncateTo != "" {
+			adjustUnit, err = timefmt.ParseUnit(truncateTo)
+		} else {
+			adjustUnit, err = timefmt.ParseUnit(roundTo)
+		}
+		if err == nil {
+			startOfWeek, err = timefmt.ParseWeekday(weekStart)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if expandTo != "" && expandTo != "start" && expandTo != "end" {
+		fmt.Fprintf(os.Stderr, "-expand must be start or end\n")
+		os.Exit(1)
+	}
+
+	if columnList != "" {
+		switch onError {
+		case "skip", "blank", "keep", "abort":
+		default:
+			fmt.Fprintf(os.Stderr, "-on-error must be skip, blank, keep or abort\n")
+			os.Exit(1)
+		}
+		if useTSV == true {
+			delimiter = "\t"
+		}
+		if err := convertColumns(os.Stdin, os.Stdout, columnList); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if checkValues == true {
+		if yearWindow != "" {
+			if _, err := fmt.Sscanf(yearWindow, "%d-%d", &firstYear, &lastYear); err != nil || firstYear > lastYear {
+				fmt.Fprintf(os.Stderr, "-years must be FIRST-LAST (e.g. 1850-2030), got %q\n", yearWindow)
+				os.Exit(1)
+			}
+		}
+		values := args
+		if len(values) == 0 {
+			values = []string{}
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				values = append(values, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(1)
+			}
+		}
+		failed, suspicious := checkDates(values, os.Stdout)
+		if failed > 0 || suspicious > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d values failed, %d suspicious\n", failed, len(values), suspicious)
+		}
+		switch {
+		case failed > 0:
+			os.Exit(1)
+		case suspicious > 0:
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
+	if normalize == true {
+		values := args
+		if len(values) == 0 {
+			values = []string{}
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				values = append(values, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(1)
+			}
+		}
+		rejects := os.Stderr
+		if rejectsFile != "" {
+			fp, err := os.Create(rejectsFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(1)
+			}
+			defer fp.Close()
+			rejects = fp
+		}
+		if n := normalizeDates(values, os.Stdout, rejects); n > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d values could not be normalized\n", n, len(values))
+		}
+		os.Exit(0)
+	}
+
+	if asJSON == true {
+		if columnList != "" || strings.ToLower(inputFormat) == "edtf" {
+			fmt.Fprintf(os.Stderr, "-json can't be combined with -columns or edtf input\n")
+			os.Exit(1)
+		}
+		var src interface{}
+		if len(args) == 0 {
+			src = components(adjustTime(time.Now()))
+		} else {
+			list := []*timefmt.Components{}
+			for _, dt := range args {
+				t, err := parseTime(dt)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "can't read %s, %s\n", dt, err)
+					os.Exit(1)
+				}
+				list = append(list, components(t))
+			}
+			src = list
+			if len(list) == 1 {
+				src = list[0]
+			}
+		}
+		buf, err := json.MarshalIndent(src, "", "    ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", buf)
+		os.Exit(0)
+	}
+
+	if strings.ToLower(inputFormat) == "edtf" && len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Missing EDTF string to convert\n")
+		os.Exit(1)
+	}
+
 	if len(args) > 0 {
 		for i, dt := range args {
-			inputDate, err = time.Parse(inputFormat, dt)
+			s, err := convert(dt)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "can't read %s, %s\n", dt, err)
 				os.Exit(1)
@@ -166,10 +931,10 @@ func main() {
 			if i > 0 {
 				fmt.Print(" ")
 			}
-			fmt.Printf("%s", inputDate.Format(outputFormat))
+			fmt.Printf("%s", s)
 		}
 		os.Exit(0)
 	}
-	inputDate = time.Now()
-	fmt.Printf("%s", inputDate.Format(outputFormat))
+	inputDate := time.Now()
+	fmt.Printf("%s", formatTime(adjustTime(inputDate), outputFormat))
 }
@@ -0,0 +1,68 @@
+//
+// chunk.go - divide sequences into parts for batch jobs.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Part returns the first and last index of part i (counting from 0)
+// when seq is divided into n parts as evenly as possible. Part sizes
+// differ by at most one and together cover seq without gaps or
+// overlaps. ok is false when the part is empty, which happens when
+// there are more parts than values.
+func Part(seq Sequence, i, n int64) (*big.Int, *big.Int, bool) {
+	if n <= 0 || i < 0 || i >= n {
+		return nil, nil, false
+	}
+	count := seq.Len()
+	// Part i runs from floor(i * count / n) up to the next part's first
+	bound := func(j int64) *big.Int {
+		b := new(big.Int).Mul(count, big.NewInt(j))
+		return b.Quo(b, big.NewInt(n))
+	}
+	first, last := bound(i), bound(i+1)
+	if first.Cmp(last) >= 0 {
+		return nil, nil, false
+	}
+	return first, last.Sub(last, big.NewInt(1)), true
+}
+
+// EachChunk calls fn with the first and last index of each run of
+// size values of seq, the last run may be shorter. It stops at the
+// first error.
+func EachChunk(seq Sequence, size int64, fn func(first, last *big.Int) error) error {
+	if size <= 0 {
+		return fmt.Errorf("chunk size must be greater than zero")
+	}
+	count := seq.Len()
+	step := big.NewInt(size)
+	for first := new(big.Int); first.Cmp(count) < 0; first.Add(first, step) {
+		last := new(big.Int).Add(first, step)
+		if last.Cmp(count) > 0 {
+			last.Set(count)
+		}
+		if err := fn(new(big.Int).Set(first), last.Sub(last, big.NewInt(1))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
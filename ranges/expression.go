@@ -0,0 +1,508 @@
+//
+// expression.go - read range expressions like "1..10..2,20,30..35",
+// "1..100,!50..59" and bash style brace templates like "a{1..3}b".
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Segment is one comma separated part of a range expression, either a
+// single value or START..END with an optional ..STEP. Exclude is set
+// for segments starting with "!".
+type Segment struct {
+	Start   string
+	End     string
+	Step    string
+	Exclude bool
+
+	seq Sequence
+}
+
+// Expression is a parsed range expression
+type Expression struct {
+	Segments []*Segment
+}
+
+// zeroPadded reports if s is written with leading zeros (e.g. "007"
+// or "0x0f")
+func zeroPadded(s string) bool {
+	digits := strings.TrimLeft(s, "+-")
+	if prefixBase(digits) > 0 {
+		digits = digits[2:]
+	}
+	return len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+}
+
+// parseSegment reads START, START..END or START..END..STEP. Values are
+// read in notation n, when n is nil integers written with a 0b, 0o or
+// 0x prefix are recognised.
+func parseSegment(text string, n *Notation) (*Segment, error) {
+	seg := &Segment{}
+	if strings.HasPrefix(text, "!") {
+		seg.Exclude = true
+		text = text[1:]
+	}
+	parts := strings.Split(text, "..")
+	if len(parts) > 3 || strings.Contains(text, "...") == true {
+		return nil, fmt.Errorf("%q is not START, START..END or START..END..STEP", text)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%q is not START, START..END or START..END..STEP", text)
+		}
+	}
+	seg.Start, seg.End, seg.Step = parts[0], parts[0], "1"
+	if len(parts) > 1 {
+		seg.End = parts[1]
+	}
+	if len(parts) > 2 {
+		seg.Step = parts[2]
+	}
+	if n == nil {
+		a, okA := DetectNotation(seg.Start)
+		b, okB := DetectNotation(seg.End)
+		if okA == true && okB == true && a.Roman == false && a.Base == b.Base {
+			a.Upper = a.Upper || b.Upper
+			n = &a
+		}
+	}
+
+	var (
+		seq Sequence
+		err error
+	)
+	if n != nil {
+		seq, err = NewNumeral(seg.Start, seg.End, seg.Step, *n)
+	} else {
+		seq, err = New(seg.Start, seg.End, seg.Step, Alphabet)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Like bash, a leading zero pads to the widest end
+	if (n == nil || n.Roman == false) && (zeroPadded(seg.Start) == true || zeroPadded(seg.End) == true) {
+		width := len(seg.Start)
+		if len(seg.End) > width {
+			width = len(seg.End)
+		}
+		seq = Padded(seq, width)
+	}
+	seg.seq = seq
+	return seg, nil
+}
+
+// Len returns the number of values in the segment
+func (seg *Segment) Len() *big.Int {
+	return seg.seq.Len()
+}
+
+// At returns the value at index i of the segment
+func (seg *Segment) At(i *big.Int) string {
+	return seg.seq.At(i)
+}
+
+// Each calls fn with each value of the segment in order, stopping at
+// the first error.
+func (seg *Segment) Each(fn func(string) error) error {
+	for it := NewIterator(seg.seq); it.Next(); {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Contains reports if value is one of the segment's values. Numbers
+// are compared by value, letters ignoring case.
+func (seg *Segment) Contains(value string) bool {
+	return seg.seq.Contains(value)
+}
+
+// splitTop splits s at sep ignoring separators inside braces
+func splitTop(s string, sep byte) []string {
+	parts := []string{}
+	depth, last := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// Parse reads a range expression. Segments are separated by commas,
+// each is a value, START..END or START..END..STEP where the values are
+// integers, decimals or letters. Segments starting with "!" are
+// excluded from the others, e.g. "1..100,!50..59". Integers written
+// with a 0b, 0o or 0x prefix keep their notation.
+func Parse(expr string) (*Expression, error) {
+	return ParseNotation(expr, nil)
+}
+
+// ParseNotation reads a range expression with its integers written in
+// notation n (e.g. Roman numerals), see Parse.
+func ParseNotation(expr string, n *Notation) (*Expression, error) {
+	e := &Expression{}
+	for _, text := range splitTop(strings.TrimSpace(expr), ',') {
+		seg, err := parseSegment(strings.TrimSpace(text), n)
+		if err != nil {
+			return nil, err
+		}
+		e.Segments = append(e.Segments, seg)
+	}
+	return e, nil
+}
+
+// numeric reports if a segment holds numbers that can be zero padded
+func (seg *Segment) numeric() bool {
+	switch r := seg.seq.(type) {
+	case *Alpha:
+		return false
+	case *Numeral:
+		return r.Notation.Roman == false
+	}
+	return true
+}
+
+// Pad zero pads the numbers of the expression to the width of its
+// widest number, letters and Roman numerals are left as they are.
+func (e *Expression) Pad() {
+	width := 0
+	for _, seg := range e.Segments {
+		if n := Widest(seg.seq); seg.numeric() == true && n > width {
+			width = n
+		}
+	}
+	for _, seg := range e.Segments {
+		if seg.numeric() == true {
+			seg.seq = Padded(seg.seq, width)
+		}
+	}
+}
+
+// Excluded reports if value is removed by an excluding segment
+func (e *Expression) Excluded(value string) bool {
+	for _, seg := range e.Segments {
+		if seg.Exclude == true && seg.Contains(value) == true {
+			return true
+		}
+	}
+	return false
+}
+
+// Each calls fn with each value of the expression in order, stopping
+// at the first error. Values aren't collected so very large
+// expressions can be walked.
+func (e *Expression) Each(fn func(string) error) error {
+	for _, seg := range e.Segments {
+		if seg.Exclude == true {
+			continue
+		}
+		err := seg.Each(func(value string) error {
+			if e.Excluded(value) == true {
+				return nil
+			}
+			return fn(value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Values returns the values of the expression
+func (e *Expression) Values() []string {
+	values := []string{}
+	e.Each(func(value string) error {
+		values = append(values, value)
+		return nil
+	})
+	return values
+}
+
+// Sequence returns the values of the expression as a Sequence.
+// Excluded values are skipped by position so the values aren't listed.
+func (e *Expression) Sequence() (Sequence, error) {
+	c, exclude := Concat{}, Concat{}
+	for _, seg := range e.Segments {
+		if seg.Exclude == true {
+			exclude = append(exclude, seg.seq)
+		} else {
+			c = append(c, seg.seq)
+		}
+	}
+	if len(exclude) == 0 {
+		return c, nil
+	}
+	f := &filtered{parts: c, exclude: exclude}
+	for _, part := range c {
+		intervals := []Interval{}
+		for _, seq := range exclude {
+			positions, err := excludedPositions(part, seq)
+			if err != nil {
+				return nil, err
+			}
+			intervals = append(intervals, positions...)
+		}
+		f.drops = append(f.drops, NewSet(intervals))
+	}
+	return f, nil
+}
+
+// asInt returns the Int under a padded or Numeral sequence, nil for
+// other sequences
+func asInt(seq Sequence) *Int {
+	switch r := seq.(type) {
+	case *Int:
+		return r
+	case *padded:
+		return asInt(r.Sequence)
+	case *Numeral:
+		return asInt(r.Sequence)
+	}
+	return nil
+}
+
+// excludedPositions returns the positions in seq of the values found
+// in exclude. Ints excluding a run of ints are worked out directly,
+// otherwise the values of seq are checked one by one.
+func excludedPositions(seq, exclude Sequence) ([]Interval, error) {
+	r, x := asInt(seq), asInt(exclude)
+	if r != nil && x != nil && (x.Step == 1 || x.Step == -1) {
+		lo, hi := big.NewInt(int64(x.Start)), big.NewInt(int64(x.End))
+		if lo.Cmp(hi) > 0 {
+			lo, hi = hi, lo
+		}
+		start, step := big.NewInt(int64(r.Start)), big.NewInt(int64(r.Step))
+		if r.Step < 0 {
+			// Count down as up from the negated values
+			lo, hi = new(big.Int).Neg(hi), new(big.Int).Neg(lo)
+			start.Neg(start)
+			step.Neg(step)
+		}
+		// Positions k with lo <= start + k*step <= hi
+		first := new(big.Int).Sub(lo, start)
+		first.Neg(first.Div(first.Neg(first), step))
+		last := new(big.Int).Sub(hi, start)
+		last.Div(last, step)
+		if first.Sign() < 0 {
+			first.SetInt64(0)
+		}
+		if n := new(big.Int).Sub(seq.Len(), big.NewInt(1)); last.Cmp(n) > 0 {
+			last = n
+		}
+		if first.Cmp(last) > 0 {
+			return nil, nil
+		}
+		if last.IsInt64() == false {
+			return nil, fmt.Errorf("%s values are too many to exclude from", seq.Len())
+		}
+		return []Interval{{int(first.Int64()), int(last.Int64())}}, nil
+	}
+	if seq.Len().Cmp(big.NewInt(setPoints)) > 0 {
+		return nil, fmt.Errorf("%s values are too many to exclude from one by one, the limit is %d", seq.Len(), setPoints)
+	}
+	positions := []Interval{}
+	for it := NewIterator(seq); it.Next(); {
+		if exclude.Contains(it.Value()) == true {
+			k := int(it.Index().Int64())
+			positions = append(positions, Interval{k, k})
+		}
+	}
+	return positions, nil
+}
+
+// filtered is the values of parts less those in exclude, drops holds
+// the positions left out of each part
+type filtered struct {
+	parts   Concat
+	drops   []*Set
+	exclude Concat
+}
+
+// Len returns the number of values
+func (f *filtered) Len() *big.Int {
+	n := new(big.Int)
+	for k, part := range f.parts {
+		n.Add(n, part.Len())
+		n.Sub(n, f.drops[k].Len())
+	}
+	return n
+}
+
+// locate returns the part holding index i and i's index in it
+func (f *filtered) locate(i *big.Int) (Sequence, *big.Int) {
+	i = new(big.Int).Set(i)
+	for k, part := range f.parts {
+		n := new(big.Int).Sub(part.Len(), f.drops[k].Len())
+		if i.Cmp(n) < 0 {
+			return part, new(big.Int).SetUint64(f.drops[k].skip(i.Uint64()))
+		}
+		i.Sub(i, n)
+	}
+	return nil, nil
+}
+
+// At returns the value at index i
+func (f *filtered) At(i *big.Int) string {
+	seq, j := f.locate(i)
+	return seq.At(j)
+}
+
+// Raw returns the value at index i for use with printf verbs
+func (f *filtered) Raw(i *big.Int) interface{} {
+	seq, j := f.locate(i)
+	return seq.Raw(j)
+}
+
+// Contains reports if value is in a part and not excluded
+func (f *filtered) Contains(value string) bool {
+	return f.parts.Contains(value) == true && f.exclude.Contains(value) == false
+}
+
+// findBrace returns the position of the first "{" and its matching
+// "}" or -1, -1 when there isn't a pair.
+func findBrace(s string) (int, int) {
+	open := strings.Index(s, "{")
+	if open < 0 {
+		return -1, -1
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return open, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// ExpandBraces expands a bash style brace template. "a{1..3}b" is a1b,
+// a2b and a3b, "{x,y}{1,2}" is x1, x2, y1 and y2. Sequences inside
+// braces can be anything Parse reads, braces with neither a sequence
+// nor a comma are left as they are.
+func ExpandBraces(s string) ([]string, error) {
+	seq, err := Braces(s)
+	if err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for it := NewIterator(seq); it.Next(); {
+		values = append(values, it.Value())
+	}
+	return values, nil
+}
+
+// Braces returns the values of a brace template (see ExpandBraces) as
+// a Sequence, each value is worked out from its index so templates
+// with many values aren't listed.
+func Braces(s string) (Sequence, error) {
+	open, close := findBrace(s)
+	if open < 0 {
+		return List{s}, nil
+	}
+	prefix, body, suffix := s[0:open], s[open+1:close], s[close+1:]
+	rest, err := Braces(suffix)
+	if err != nil {
+		return nil, err
+	}
+	var middle Sequence
+	if parts := splitTop(body, ','); len(parts) > 1 {
+		alternatives := Concat{}
+		for _, alt := range parts {
+			seq, err := Braces(alt)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, seq)
+		}
+		middle = alternatives
+	} else if strings.Contains(body, "..") {
+		e, err := Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		if middle, err = e.Sequence(); err != nil {
+			return nil, err
+		}
+	} else {
+		// Not a brace expression, keep the text
+		prefix, middle = s[0:close+1], List{""}
+	}
+	p, _ := NewProduct([]Sequence{List{prefix}, middle, rest}, nil, "")
+	return template{p}, nil
+}
+
+// template is the values of a brace template, the product of its
+// text and the values in braces
+type template struct {
+	*Product
+}
+
+// Raw returns the value at index i, as a number when it is one
+func (t template) Raw(i *big.Int) interface{} {
+	return Number(t.At(i))
+}
+
+// Contains reports if value is one of the template's values, they are
+// checked one by one
+func (t template) Contains(value string) bool {
+	for it := NewIterator(t); it.Next(); {
+		if it.Value() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBraceTemplate reports if s has a brace expression to expand
+func IsBraceTemplate(s string) bool {
+	open, _ := findBrace(s)
+	return open >= 0
+}
+
+// Expand returns the values of a brace template or range expression
+func Expand(s string) ([]string, error) {
+	if IsBraceTemplate(s) == true {
+		return ExpandBraces(s)
+	}
+	e, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return e.Values(), nil
+}
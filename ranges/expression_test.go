@@ -0,0 +1,129 @@
+//
+// expression_test.go - tests for range expressions and brace templates.
+// sequences along with an iterator to walk them.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"1..3", "1,2,3"},
+		{"1..10..3,20", "1,4,7,10,20"},
+		{"1..10,!3..8", "1,2,9,10"},
+		{"0.5..1..0.25", "0.50,0.75,1.00"},
+		{"08..10", "08,09,10"},
+		{"0x9..0xb", "0x9,0xa,0xb"},
+		{"a..c,!b", "a,c"},
+		{"cd..cm", "cd,ce,cf,cg,ch,ci,cj,ck,cl,cm"},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %s", test.expr, err)
+			continue
+		}
+		if result := strings.Join(e.Values(), ","); result != test.expected {
+			t.Errorf("Parse(%q) yields %s, expected %s", test.expr, result, test.expected)
+		}
+	}
+	for _, expr := range []string{"", "1...3", "1....3", "1..", "..3", "1..3..", "1..3..1..2", "1,,2", "!"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
+
+func TestExpressionSequence(t *testing.T) {
+	for _, expr := range []string{
+		"1..10,!3..8",
+		"10..1,!3..8",
+		"1..20..3,!4..10,!19",
+		"20..1..3,!5..14",
+		"-10..10..4,!-6..2",
+		"1..10,!2..8..2",
+		"1..10,!1..10",
+		"1..10,!0..100",
+		"1..3,5..7,!2,!6,!6..7",
+		"a..e,!b..c",
+		"0.5..3..0.5,!1..2",
+		"0x1..0x10,!0x3..0xc",
+		"01..12,!05..08",
+	} {
+		e, err := Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %s", expr, err)
+			continue
+		}
+		seq, err := e.Sequence()
+		if err != nil {
+			t.Errorf("%q Sequence() returned %s", expr, err)
+			continue
+		}
+		expected := strings.Join(e.Values(), ",")
+		if result := strings.Join(values(seq), ","); result != expected {
+			t.Errorf("%q Sequence() yields %s, expected %s", expr, result, expected)
+		}
+	}
+	// Excluding from a large range doesn't list it
+	e, _ := Parse("1..1000000000000,!2..999999999999")
+	seq, err := e.Sequence()
+	if err != nil {
+		t.Fatalf("Sequence() returned %s", err)
+	}
+	if result := strings.Join(values(seq), ","); result != "1,1000000000000" {
+		t.Errorf("expected 1,1000000000000, got %s", result)
+	}
+}
+
+func TestBraces(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"a{1..3}b", "a1b,a2b,a3b"},
+		{"{x,y}{01..02}", "x01,x02,y01,y02"},
+		{"{a,b{1,2}}c", "ac,b1c,b2c"},
+		{"x{foo}y{1,2}", "x{foo}y1,x{foo}y2"},
+		{"{a,}z", "az,z"},
+	}
+	for _, test := range tests {
+		seq, err := Braces(test.template)
+		if err != nil {
+			t.Errorf("Braces(%q) returned %s", test.template, err)
+			continue
+		}
+		if result := strings.Join(values(seq), ","); result != test.expected {
+			t.Errorf("Braces(%q) yields %s, expected %s", test.template, result, test.expected)
+		}
+	}
+	seq, _ := Braces("{1..1000000}{1..1000}")
+	if n := seq.Len().String(); n != "1000000000" {
+		t.Errorf("expected 1000000000 values, got %s", n)
+	}
+	if seq.Contains("11") == false {
+		t.Errorf("expected 11 in the template")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// numeral.go - integers written in other bases and as Roman numerals.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// roman matches Roman numerals from I to MMMCMXCIX
+var roman = regexp.MustCompile(`(?i)^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+
+// romanDigits are the Roman numeral values from largest to smallest
+var romanDigits = []struct {
+	value   int
+	letters string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// prefixes are the bases written with a prefix
+var prefixes = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+
+// Notation says how integers are written, in a Base from 2 to 36 or
+// as Roman numerals (from 1 to 3999). Prefix adds 0b, 0o or 0x to
+// binary, octal and hexadecimal values. Upper writes letters in upper
+// case.
+type Notation struct {
+	Base   int
+	Prefix bool
+	Upper  bool
+	Roman  bool
+}
+
+// IsRoman reports if s is a Roman numeral
+func IsRoman(s string) bool {
+	return s != "" && roman.MatchString(s)
+}
+
+// prefixBase returns the base of digits written with a 0b, 0o or 0x
+// prefix, or 0 when there is no prefix
+func prefixBase(digits string) int {
+	if len(digits) > 2 {
+		for base, prefix := range prefixes {
+			if strings.EqualFold(digits[0:2], prefix) {
+				return base
+			}
+		}
+	}
+	return 0
+}
+
+// DetectNotation returns the notation of an integer written with a
+// 0b, 0o or 0x prefix, ok is false for others. Upper follows the case
+// of the letters in s. Roman numerals aren't detected as they are also
+// words (e.g. "cd" to "cm" is a range of letters).
+func DetectNotation(s string) (Notation, bool) {
+	digits := strings.TrimLeft(s, "+-")
+	if base := prefixBase(digits); base > 0 {
+		n := Notation{Base: base, Prefix: true, Upper: strings.ToLower(digits[2:]) != digits[2:]}
+		_, err := n.Parse(s)
+		return n, err == nil
+	}
+	return Notation{}, false
+}
+
+// Parse reads an integer written in the notation, a 0b, 0o or 0x
+// prefix is read whatever the base.
+func (n Notation) Parse(s string) (*big.Int, error) {
+	if n.Roman == true {
+		if IsRoman(s) == false {
+			// Numbers in decimal are read too
+			if v, ok := new(big.Int).SetString(s, 10); ok == true {
+				return v, nil
+			}
+			return nil, fmt.Errorf("%q is not a Roman numeral", s)
+		}
+		value, rest := 0, strings.ToUpper(s)
+		for _, d := range romanDigits {
+			for strings.HasPrefix(rest, d.letters) {
+				value += d.value
+				rest = rest[len(d.letters):]
+			}
+		}
+		return big.NewInt(int64(value)), nil
+	}
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[0:1], digits[1:]
+	}
+	base := n.Base
+	if b := prefixBase(digits); b > 0 {
+		base, digits = b, digits[2:]
+	}
+	v, ok := new(big.Int).SetString(sign+digits, base)
+	if ok == false {
+		return nil, fmt.Errorf("%q is not a base %d integer", s, base)
+	}
+	return v, nil
+}
+
+// Format writes v in the notation
+func (n Notation) Format(v *big.Int) string {
+	if n.Roman == true {
+		value, out := int(v.Int64()), ""
+		for _, d := range romanDigits {
+			for value >= d.value {
+				out += d.letters
+				value -= d.value
+			}
+		}
+		if n.Upper == false {
+			return strings.ToLower(out)
+		}
+		return out
+	}
+	s := new(big.Int).Abs(v).Text(n.Base)
+	if n.Upper == true {
+		s = strings.ToUpper(s)
+	}
+	if prefix, ok := prefixes[n.Base]; ok == true && n.Prefix == true {
+		s = prefix + s
+	}
+	if v.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// Numeral is a sequence of integers written in a Notation
+type Numeral struct {
+	Sequence
+	Notation Notation
+}
+
+// NewNumeral returns the integers from start to end by step, all
+// three written in notation n.
+func NewNumeral(start, end, step string, n Notation) (*Numeral, error) {
+	if n.Base < 2 || n.Base > 36 {
+		return nil, fmt.Errorf("base must be from 2 to 36, got %d", n.Base)
+	}
+	if _, ok := prefixes[n.Base]; n.Prefix == true && ok == false {
+		return nil, fmt.Errorf("base %d has no prefix, only bases 2, 8 and 16 do", n.Base)
+	}
+	values := []string{}
+	for _, s := range []string{start, end} {
+		v, err := n.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if n.Roman == true && (v.Sign() <= 0 || v.Cmp(big.NewInt(3999)) > 0) {
+			return nil, fmt.Errorf("Roman numerals go from 1 to 3999")
+		}
+		values = append(values, v.String())
+	}
+	// The increment is a number unless written with a prefix or as a
+	// Roman numeral
+	inc, ok := new(big.Int).SetString(step, 10)
+	if sn, detected := DetectNotation(step); detected == true {
+		inc, _ = sn.Parse(step)
+	} else if n.Roman == true && IsRoman(step) == true {
+		inc, _ = n.Parse(step)
+	} else if ok == false {
+		return nil, fmt.Errorf("increment %q must be an integer", step)
+	}
+	seq, err := New(values[0], values[1], inc.String(), Alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return &Numeral{Sequence: seq, Notation: n}, nil
+}
+
+// At returns the value at index i
+func (r *Numeral) At(i *big.Int) string {
+	v, _ := new(big.Int).SetString(r.Sequence.At(i), 10)
+	return r.Notation.Format(v)
+}
+
+// Contains reports if value, written in the notation, is in the sequence
+func (r *Numeral) Contains(value string) bool {
+	v, err := r.Notation.Parse(value)
+	return err == nil && r.Sequence.Contains(v.String())
+}
@@ -0,0 +1,80 @@
+//
+// numeral_test.go - tests for integers written in other bases and as
+// Roman numerals.
+// sequences along with an iterator to walk them.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectNotation(t *testing.T) {
+	tests := []struct {
+		value string
+		base  int
+		ok    bool
+	}{
+		{"0x1F", 16, true},
+		{"0o17", 8, true},
+		{"0b101", 2, true},
+		{"-0xa", 16, true},
+		{"0b102", 0, false},
+		{"10", 0, false},
+		// Roman numerals are words too
+		{"cd", 0, false},
+		{"xii", 0, false},
+		{"MMXVI", 0, false},
+	}
+	for _, test := range tests {
+		n, ok := DetectNotation(test.value)
+		if ok != test.ok || (ok == true && n.Base != test.base) {
+			t.Errorf("DetectNotation(%q) is %+v, %t, expected base %d, %t", test.value, n, ok, test.base, test.ok)
+		}
+	}
+}
+
+func TestNumeral(t *testing.T) {
+	tests := []struct {
+		start, end, step string
+		notation         Notation
+		expected         string
+	}{
+		{"viii", "xii", "1", Notation{Base: 10, Roman: true}, "viii,ix,x,xi,xii"},
+		{"1", "5", "ii", Notation{Base: 10, Roman: true, Upper: true}, "I,III,V"},
+		{"cd", "cm", "100", Notation{Base: 10, Roman: true, Upper: true}, "CD,D,DC,DCC,DCCC,CM"},
+		{"a", "10", "1", Notation{Base: 16}, "a,b,c,d,e,f,10"},
+		{"0x8", "0x11", "4", Notation{Base: 16, Prefix: true}, "0x8,0xc,0x10"},
+		{"1", "100", "1", Notation{Base: 2, Prefix: true}, "0b1,0b10,0b11,0b100"},
+	}
+	for _, test := range tests {
+		seq, err := NewNumeral(test.start, test.end, test.step, test.notation)
+		if err != nil {
+			t.Errorf("NewNumeral(%q, %q, %q) returned %s", test.start, test.end, test.step, err)
+			continue
+		}
+		if result := strings.Join(values(seq), ","); result != test.expected {
+			t.Errorf("NewNumeral(%q, %q, %q) yields %s, expected %s", test.start, test.end, test.step, result, test.expected)
+		}
+	}
+	if _, err := NewNumeral("0", "5", "1", Notation{Base: 10, Roman: true}); err == nil {
+		t.Errorf("expected an error for Roman numeral zero")
+	}
+}
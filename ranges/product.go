@@ -0,0 +1,226 @@
+//
+// product.go - lists of values and cartesian products of sequences.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Number converts a value to an int or float64 when it is one so
+// printf verbs like %d and %f can be used with it.
+func Number(s string) interface{} {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// List is a sequence of values held in memory
+type List []string
+
+// Len returns the number of values
+func (l List) Len() *big.Int {
+	return big.NewInt(int64(len(l)))
+}
+
+// At returns the value at index i
+func (l List) At(i *big.Int) string {
+	return l[i.Int64()]
+}
+
+// Raw returns the value at index i, as a number when it is one
+func (l List) Raw(i *big.Int) interface{} {
+	return Number(l[i.Int64()])
+}
+
+// Contains reports if value is in the list
+func (l List) Contains(value string) bool {
+	for _, v := range l {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Concat is sequences one after another
+type Concat []Sequence
+
+// Len returns the number of values
+func (c Concat) Len() *big.Int {
+	n := new(big.Int)
+	for _, seq := range c {
+		n.Add(n, seq.Len())
+	}
+	return n
+}
+
+// locate returns the sequence holding index i and i's index in it
+func (c Concat) locate(i *big.Int) (Sequence, *big.Int) {
+	i = new(big.Int).Set(i)
+	for _, seq := range c {
+		n := seq.Len()
+		if i.Cmp(n) < 0 {
+			return seq, i
+		}
+		i.Sub(i, n)
+	}
+	return nil, nil
+}
+
+// At returns the value at index i
+func (c Concat) At(i *big.Int) string {
+	seq, j := c.locate(i)
+	return seq.At(j)
+}
+
+// Raw returns the value at index i for use with printf verbs
+func (c Concat) Raw(i *big.Int) interface{} {
+	seq, j := c.locate(i)
+	return seq.Raw(j)
+}
+
+// Contains reports if value is in one of the sequences
+func (c Concat) Contains(value string) bool {
+	for _, seq := range c {
+		if seq.Contains(value) == true {
+			return true
+		}
+	}
+	return false
+}
+
+// Spec returns the values of a range expression or brace template as
+// a sequence
+func Spec(s string) (Sequence, error) {
+	return SpecNotation(s, nil)
+}
+
+// SpecNotation is Spec with the integers of a range expression written
+// in notation n
+func SpecNotation(s string, n *Notation) (Sequence, error) {
+	if IsBraceTemplate(s) == true {
+		return Braces(s)
+	}
+	e, err := ParseNotation(s, n)
+	if err != nil {
+		return nil, err
+	}
+	return e.Sequence()
+}
+
+// Product is the cartesian product of sequences, each value is a tuple
+// holding one value from each sequence. Order lists the sequences
+// (counting from 0) from the one changing slowest to the one changing
+// fastest, values are written joined by Delimiter.
+type Product struct {
+	Sequences []Sequence
+	Order     []int
+	Delimiter string
+}
+
+// NewProduct returns the product of seqs. order lists the sequences
+// (counting from 1) from the slowest changing to the fastest, when it
+// is empty the last sequence changes fastest like nested loops.
+func NewProduct(seqs []Sequence, order []int, delimiter string) (*Product, error) {
+	p := &Product{Sequences: seqs, Delimiter: delimiter}
+	if len(order) == 0 {
+		for i := range seqs {
+			p.Order = append(p.Order, i)
+		}
+		return p, nil
+	}
+	if len(order) != len(seqs) {
+		return nil, fmt.Errorf("order needs %d positions, got %d", len(seqs), len(order))
+	}
+	seen := map[int]bool{}
+	for _, n := range order {
+		if n < 1 || n > len(seqs) || seen[n] == true {
+			return nil, fmt.Errorf("order must list each of 1 to %d once", len(seqs))
+		}
+		seen[n] = true
+		p.Order = append(p.Order, n-1)
+	}
+	return p, nil
+}
+
+// Len returns the number of tuples
+func (p *Product) Len() *big.Int {
+	n := big.NewInt(1)
+	for _, seq := range p.Sequences {
+		n.Mul(n, seq.Len())
+	}
+	return n
+}
+
+// indexes returns the index into each sequence of tuple i
+func (p *Product) indexes(i *big.Int) []*big.Int {
+	out := make([]*big.Int, len(p.Sequences))
+	rest := new(big.Int).Set(i)
+	for k := len(p.Order) - 1; k >= 0; k-- {
+		j := p.Order[k]
+		out[j] = new(big.Int)
+		rest.QuoRem(rest, p.Sequences[j].Len(), out[j])
+	}
+	return out
+}
+
+// Tuple returns the values of tuple i
+func (p *Product) Tuple(i *big.Int) []string {
+	values := []string{}
+	for j, k := range p.indexes(i) {
+		values = append(values, p.Sequences[j].At(k))
+	}
+	return values
+}
+
+// At returns tuple i joined by Delimiter
+func (p *Product) At(i *big.Int) string {
+	return strings.Join(p.Tuple(i), p.Delimiter)
+}
+
+// Raw returns tuple i as a slice of values for use with printf verbs
+func (p *Product) Raw(i *big.Int) interface{} {
+	values := []interface{}{}
+	for j, k := range p.indexes(i) {
+		values = append(values, p.Sequences[j].Raw(k))
+	}
+	return values
+}
+
+// Contains reports if value, joined by Delimiter, is one of the tuples
+func (p *Product) Contains(value string) bool {
+	values := strings.Split(value, p.Delimiter)
+	if len(values) != len(p.Sequences) {
+		return false
+	}
+	for j, v := range values {
+		if p.Sequences[j].Contains(v) == false {
+			return false
+		}
+	}
+	return true
+}
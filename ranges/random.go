@@ -0,0 +1,71 @@
+//
+// random.go - pick values from sequences at random without listing
+// them.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"math/rand"
+)
+
+// Permutation is a pseudo random ordering of 0 to n - 1. Each
+// position is computed from a Feistel network (walking the cycle
+// until the result is below n) so no list of values is kept.
+type Permutation struct {
+	n    uint64
+	half uint
+	mask uint64
+	keys [4]uint64
+}
+
+// NewPermutation returns a random ordering of 0 to n - 1 using rng
+func NewPermutation(n uint64, rng *rand.Rand) *Permutation {
+	bits := uint(0)
+	for v := n - 1; v > 0; v = v >> 1 {
+		bits++
+	}
+	p := &Permutation{n: n, half: (bits + 1) / 2}
+	p.mask = (uint64(1) << p.half) - 1
+	for i := range p.keys {
+		p.keys[i] = uint64(rng.Int63())<<1 ^ uint64(rng.Int63())
+	}
+	return p
+}
+
+// mix is the SplitMix64 finalizer used as the round function
+func mix(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// At returns the value at position i of the permutation
+func (p *Permutation) At(i uint64) uint64 {
+	x := i
+	for {
+		l, r := x>>p.half, x&p.mask
+		for _, key := range p.keys {
+			l, r = r, l^(mix(r^key)&p.mask)
+		}
+		x = l<<p.half | r
+		if x < p.n {
+			return x
+		}
+	}
+}
@@ -0,0 +1,541 @@
+//
+// sequence.go - integer, big integer, decimal and alphabetic
+// sequences along with an iterator to walk them.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Sequence is a range of values that can be read by index, so very
+// long ranges don't need to be held in memory. Indexes run from 0 to
+// Len() - 1.
+type Sequence interface {
+	// Len returns the number of values
+	Len() *big.Int
+	// At returns the value at index i as text
+	At(i *big.Int) string
+	// Raw returns the value at index i for use with printf verbs
+	// (an int, *big.Int, float64 or string)
+	Raw(i *big.Int) interface{}
+	// Contains reports if value is one of the values
+	Contains(value string) bool
+}
+
+// Int is a sequence of ints from Start to End by Step. Step's sign
+// follows the direction from Start to End.
+type Int struct {
+	Start int
+	End   int
+	Step  int
+}
+
+// NewInt returns the ints from start to end by step, counting down
+// when start is greater than end.
+func NewInt(start, end, step int) (*Int, error) {
+	if step == 0 {
+		return nil, fmt.Errorf("increment must be non-zero")
+	}
+	if (start <= end) != (step > 0) {
+		step = -step
+	}
+	return &Int{Start: start, End: end, Step: step}, nil
+}
+
+// Len returns the number of values. Differences are taken as unsigned
+// so they can't overflow.
+func (r *Int) Len() *big.Int {
+	span, step := uint64(r.End)-uint64(r.Start), uint64(r.Step)
+	if r.Step < 0 {
+		span, step = uint64(r.Start)-uint64(r.End), -uint64(r.Step)
+	}
+	n := new(big.Int).SetUint64(span / step)
+	return n.Add(n, big.NewInt(1))
+}
+
+func (r *Int) value(i *big.Int) int {
+	return int(uint64(r.Start) + i.Uint64()*uint64(r.Step))
+}
+
+// At returns the value at index i
+func (r *Int) At(i *big.Int) string {
+	return strconv.Itoa(r.value(i))
+}
+
+// Raw returns the value at index i as an int
+func (r *Int) Raw(i *big.Int) interface{} {
+	return r.value(i)
+}
+
+// Contains reports if value is one of the sequence's ints
+func (r *Int) Contains(value string) bool {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	if r.Step > 0 {
+		return v >= r.Start && v <= r.End && (uint64(v)-uint64(r.Start))%uint64(r.Step) == 0
+	}
+	return v <= r.Start && v >= r.End && (uint64(r.Start)-uint64(v))%(-uint64(r.Step)) == 0
+}
+
+// progression is first + i * step for i from 0 to count - 1, it is
+// the basis of the BigInt, Decimal and Alpha sequences.
+type progression struct {
+	first *big.Rat
+	step  *big.Rat
+	count *big.Int
+}
+
+func newProgression(first, last, step *big.Rat) (*progression, error) {
+	if step.Sign() == 0 {
+		return nil, fmt.Errorf("increment must be non-zero")
+	}
+	step = new(big.Rat).Set(step)
+	if (first.Cmp(last) <= 0) != (step.Sign() > 0) {
+		step.Neg(step)
+	}
+	span := new(big.Rat).Sub(last, first)
+	span.Quo(span, step)
+	count := new(big.Int).Quo(span.Num(), span.Denom())
+	count.Add(count, big.NewInt(1))
+	return &progression{first: first, step: step, count: count}, nil
+}
+
+func (p *progression) Len() *big.Int {
+	return new(big.Int).Set(p.count)
+}
+
+func (p *progression) nth(i *big.Int) *big.Rat {
+	v := new(big.Rat).SetInt(i)
+	v.Mul(v, p.step)
+	return v.Add(v, p.first)
+}
+
+// contains reports if v is first + i * step for a whole i in range
+func (p *progression) contains(v *big.Rat) bool {
+	i := new(big.Rat).Sub(v, p.first)
+	i.Quo(i, p.step)
+	return i.IsInt() && i.Sign() >= 0 && i.Num().Cmp(p.count) < 0
+}
+
+// BigInt is a sequence of integers of any size
+type BigInt struct {
+	*progression
+}
+
+// NewBigInt returns the integers from start to end by step
+func NewBigInt(start, end, step *big.Int) (*BigInt, error) {
+	p, err := newProgression(new(big.Rat).SetInt(start), new(big.Rat).SetInt(end), new(big.Rat).SetInt(step))
+	if err != nil {
+		return nil, err
+	}
+	return &BigInt{p}, nil
+}
+
+// At returns the value at index i
+func (r *BigInt) At(i *big.Int) string {
+	return r.nth(i).Num().String()
+}
+
+// Raw returns the value at index i as a *big.Int
+func (r *BigInt) Raw(i *big.Int) interface{} {
+	return r.nth(i).Num()
+}
+
+// Contains reports if value is one of the sequence's integers
+func (r *BigInt) Contains(value string) bool {
+	v, ok := new(big.Int).SetString(value, 10)
+	return ok && r.contains(new(big.Rat).SetInt(v))
+}
+
+// Decimal is a sequence of decimals computed exactly as Start plus a
+// multiple of Step so there is no floating point drift. Values are
+// written with Places digits after the decimal point rounded using
+// Rounding.
+type Decimal struct {
+	*progression
+	Places   int
+	Rounding Rounding
+}
+
+// NewDecimal returns the decimals from start to end by step
+func NewDecimal(start, end, step *big.Rat, places int) (*Decimal, error) {
+	p, err := newProgression(start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	return &Decimal{progression: p, Places: places}, nil
+}
+
+// At returns the value at index i
+func (r *Decimal) At(i *big.Int) string {
+	return r.Rounding.Format(r.nth(i), r.Places)
+}
+
+// Raw returns the value at index i as a float64
+func (r *Decimal) Raw(i *big.Int) interface{} {
+	f, _ := r.nth(i).Float64()
+	return f
+}
+
+// Contains reports if value is one of the sequence's decimals
+func (r *Decimal) Contains(value string) bool {
+	v, ok := new(big.Rat).SetString(value)
+	return ok && r.contains(v)
+}
+
+// Alpha is a sequence of letters. Letters carry like spreadsheet
+// columns (Z is followed by AA). Values mixing letters and digits
+// (e.g. "A01") count like an odometer, each position keeping its
+// kind. Single case alphabets match either case and the sequence is
+// written in the case of its start.
+type Alpha struct {
+	*progression
+	letters []rune
+	fold    bool
+	shape   []bool
+	lower   bool
+}
+
+// NewAlpha returns the letter sequence from start to end by step using
+// alphabet, the letters in order.
+func NewAlpha(start, end string, step int, alphabet string) (*Alpha, error) {
+	r := &Alpha{}
+	r.fold = strings.ToUpper(alphabet) == alphabet || strings.ToLower(alphabet) == alphabet
+	if r.fold == true {
+		alphabet = strings.ToUpper(alphabet)
+	}
+	seen := map[rune]bool{}
+	for _, c := range alphabet {
+		if seen[c] == true {
+			return nil, fmt.Errorf("%q is repeated in the alphabet", c)
+		}
+		seen[c] = true
+		r.letters = append(r.letters, c)
+	}
+	if len(r.letters) < 2 {
+		return nil, fmt.Errorf("the alphabet needs at least two letters")
+	}
+	first, shape, err := r.number(start)
+	if err != nil {
+		return nil, err
+	}
+	last, endShape, err := r.number(end)
+	if err != nil {
+		return nil, err
+	}
+	if sameShape(shape, endShape) == false {
+		return nil, fmt.Errorf("%q and %q need letters and digits in the same places", start, end)
+	}
+	r.shape = shape
+	r.lower = r.fold == true && strings.ToUpper(start) != start
+	r.progression, err = newProgression(new(big.Rat).SetInt(first), new(big.Rat).SetInt(last), big.NewRat(int64(step), 1))
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// letterIndex returns the position of c in the alphabet or -1
+func (r *Alpha) letterIndex(c rune) int {
+	if r.fold == true {
+		c = unicode.ToUpper(c)
+	}
+	for i, l := range r.letters {
+		if l == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// number converts letters to their position in the sequence, only
+// letters are numbered like spreadsheet columns (A is 1, AA is 27).
+// For mixed values the shape (true for a letter) is returned.
+func (r *Alpha) number(s string) (*big.Int, []bool, error) {
+	shape := []bool{}
+	letters := 0
+	for _, c := range s {
+		switch {
+		case r.letterIndex(c) >= 0:
+			shape = append(shape, true)
+			letters++
+		case '0' <= c && c <= '9':
+			shape = append(shape, false)
+		default:
+			return nil, nil, fmt.Errorf("%q in %q is not in the alphabet", c, s)
+		}
+	}
+	if letters == 0 {
+		return nil, nil, fmt.Errorf("%q has no letters", s)
+	}
+	if letters == len(shape) {
+		shape = nil
+	}
+	n := new(big.Int)
+	k := big.NewInt(int64(len(r.letters)))
+	ten := big.NewInt(10)
+	i := 0
+	for _, c := range s {
+		radix, digit := k, int64(r.letterIndex(c))
+		if shape == nil {
+			digit++
+		} else if shape[i] == false {
+			radix, digit = ten, int64(c-'0')
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(digit))
+		i++
+	}
+	return n, shape, nil
+}
+
+// At returns the value at index i
+func (r *Alpha) At(i *big.Int) string {
+	n := new(big.Int).Set(r.nth(i).Num())
+	k := big.NewInt(int64(len(r.letters)))
+	ten := big.NewInt(10)
+	d := new(big.Int)
+	out := []rune{}
+	if r.shape == nil {
+		one := big.NewInt(1)
+		for n.Sign() > 0 {
+			n.Sub(n, one)
+			n.QuoRem(n, k, d)
+			out = append([]rune{r.letters[d.Int64()]}, out...)
+		}
+	} else {
+		out = make([]rune, len(r.shape))
+		for j := len(r.shape) - 1; j >= 0; j-- {
+			if r.shape[j] == true {
+				n.QuoRem(n, k, d)
+				out[j] = r.letters[d.Int64()]
+			} else {
+				n.QuoRem(n, ten, d)
+				out[j] = rune('0' + d.Int64())
+			}
+		}
+	}
+	if r.lower == true {
+		return strings.ToLower(string(out))
+	}
+	return string(out)
+}
+
+// Raw returns the value at index i
+func (r *Alpha) Raw(i *big.Int) interface{} {
+	return r.At(i)
+}
+
+// Contains reports if value is in the sequence
+func (r *Alpha) Contains(value string) bool {
+	n, shape, err := r.number(value)
+	return err == nil && sameShape(shape, r.shape) && r.contains(new(big.Rat).SetInt(n))
+}
+
+// sameShape reports if two letter values count the same way
+func sameShape(a, b []bool) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isInteger reports if s is written as an integer
+func isInteger(s string) bool {
+	return strings.ContainsAny(s, ".eE") == false
+}
+
+// DecimalPlaces returns the number of decimal places a number needs,
+// the digits written after the decimal point less any exponent (e.g.
+// "2.5e-3" needs 4 and "1e2" none).
+func DecimalPlaces(s string) int {
+	s = strings.ToLower(s)
+	places := 0
+	if i := strings.Index(s, "e"); i >= 0 {
+		if exp, err := strconv.Atoi(s[i+1:]); err == nil {
+			places = -exp
+		}
+		s = s[0:i]
+	}
+	if i := strings.Index(s, "."); i >= 0 {
+		places += len(s) - i - 1
+	}
+	if places < 0 {
+		return 0
+	}
+	return places
+}
+
+// IsLetters reports if a range value is alphabetic rather than a number
+func IsLetters(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil || s == "" {
+		return false
+	}
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsDigit(r) == false }) >= 0
+}
+
+// New returns the sequence from start to end by step choosing the
+// domain from the values. Letters give an Alpha sequence using
+// alphabet, values with a decimal point or exponent a Decimal with as
+// many places as the values use, integers too large for an int a
+// BigInt and other integers an Int.
+func New(start, end, step, alphabet string) (Sequence, error) {
+	if IsLetters(start) == true || IsLetters(end) == true {
+		n, err := strconv.Atoi(step)
+		if err != nil {
+			return nil, fmt.Errorf("increment %q must be an integer for letters", step)
+		}
+		return NewAlpha(start, end, n, alphabet)
+	}
+	if isInteger(start) == false || isInteger(end) == false || isInteger(step) == false {
+		values := []*big.Rat{}
+		places := 0
+		for _, s := range []string{start, end, step} {
+			v, ok := new(big.Rat).SetString(s)
+			if ok == false {
+				return nil, fmt.Errorf("%q is not a number", s)
+			}
+			values = append(values, v)
+			if n := DecimalPlaces(s); n > places {
+				places = n
+			}
+		}
+		return NewDecimal(values[0], values[1], values[2], places)
+	}
+	a, errA := strconv.Atoi(start)
+	b, errB := strconv.Atoi(end)
+	c, errC := strconv.Atoi(step)
+	if errA == nil && errB == nil && errC == nil {
+		return NewInt(a, b, c)
+	}
+	values := []*big.Int{}
+	for _, s := range []string{start, end, step} {
+		v, ok := new(big.Int).SetString(s, 10)
+		if ok == false {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		values = append(values, v)
+	}
+	return NewBigInt(values[0], values[1], values[2])
+}
+
+// padded is a sequence written with leading zeros
+type padded struct {
+	Sequence
+	width int
+}
+
+// At returns the value at index i zero padded after any sign or prefix
+func (p *padded) At(i *big.Int) string {
+	s := p.Sequence.At(i)
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[0:1], s[1:]
+	}
+	// Zeros go after a base prefix
+	if prefixBase(s) > 0 {
+		sign, s = sign+s[0:2], s[2:]
+	}
+	if n := p.width - len(sign) - len(s); n > 0 {
+		s = strings.Repeat("0", n) + s
+	}
+	return sign + s
+}
+
+// Padded returns seq with its values zero padded to width
+func Padded(seq Sequence, width int) Sequence {
+	return &padded{Sequence: seq, width: width}
+}
+
+// Widest returns the width of the widest value of seq. Values widen
+// away from zero so a sequence counting steadily is widest at one of
+// its ends, concatenated sequences are measured part by part.
+func Widest(seq Sequence) int {
+	if c, ok := seq.(Concat); ok == true {
+		w := 0
+		for _, part := range c {
+			if n := Widest(part); n > w {
+				w = n
+			}
+		}
+		return w
+	}
+	n := seq.Len()
+	if n.Sign() == 0 {
+		return 0
+	}
+	w := len(seq.At(new(big.Int)))
+	if last := len(seq.At(n.Sub(n, big.NewInt(1)))); last > w {
+		w = last
+	}
+	return w
+}
+
+// Iterator walks a sequence in order
+//
+//	it := ranges.NewIterator(seq)
+//	for it.Next() {
+//		fmt.Println(it.Value())
+//	}
+type Iterator struct {
+	seq Sequence
+	i   *big.Int
+	n   *big.Int
+}
+
+// NewIterator returns an iterator positioned before the first value
+func NewIterator(seq Sequence) *Iterator {
+	return &Iterator{seq: seq, i: big.NewInt(-1), n: seq.Len()}
+}
+
+// Next moves to the next value, it returns false when there are none
+func (it *Iterator) Next() bool {
+	if it.i.Cmp(it.n) >= 0 {
+		return false
+	}
+	it.i.Add(it.i, big.NewInt(1))
+	return it.i.Cmp(it.n) < 0
+}
+
+// Index returns the index of the current value
+func (it *Iterator) Index() *big.Int {
+	return new(big.Int).Set(it.i)
+}
+
+// Value returns the current value
+func (it *Iterator) Value() string {
+	return it.seq.At(it.i)
+}
+
+// Raw returns the current value for use with printf verbs
+func (it *Iterator) Raw() interface{} {
+	return it.seq.Raw(it.i)
+}
@@ -0,0 +1,274 @@
+//
+// sequence_test.go - tests for the integer, big integer, decimal and
+// alphabetic sequences and the iterator.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// values walks seq with an Iterator
+func values(seq Sequence) []string {
+	out := []string{}
+	for it := NewIterator(seq); it.Next(); {
+		out = append(out, it.Value())
+	}
+	return out
+}
+
+func TestNewInt(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		expected         string
+	}{
+		{5, 5, 1, "5"},
+		{5, 5, -3, "5"},
+		{1, 10, 3, "1,4,7,10"},
+		{1, 10, -3, "1,4,7,10"},
+		{10, 1, 3, "10,7,4,1"},
+		{10, 1, -3, "10,7,4,1"},
+		{-2, 2, 2, "-2,0,2"},
+		{math.MinInt64, math.MinInt64 + 2, 1, "-9223372036854775808,-9223372036854775807,-9223372036854775806"},
+		{math.MaxInt64 - 2, math.MaxInt64, 1, "9223372036854775805,9223372036854775806,9223372036854775807"},
+		{math.MaxInt64, math.MinInt64, math.MaxInt64, "9223372036854775807,0,-9223372036854775807"},
+	}
+	for _, test := range tests {
+		seq, err := NewInt(test.start, test.end, test.step)
+		if err != nil {
+			t.Errorf("NewInt(%d, %d, %d) returned %s", test.start, test.end, test.step, err)
+			continue
+		}
+		if result := strings.Join(values(seq), ","); result != test.expected {
+			t.Errorf("NewInt(%d, %d, %d) yields %s, expected %s", test.start, test.end, test.step, result, test.expected)
+		}
+	}
+	if _, err := NewInt(1, 10, 0); err == nil {
+		t.Errorf("expected an error for a zero increment")
+	}
+}
+
+func TestIntLen(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		expected         string
+	}{
+		{1, 1, 1, "1"},
+		{1, 10, 1, "10"},
+		{1, 10, 4, "3"},
+		{10, 1, 4, "3"},
+		{math.MinInt64, math.MaxInt64, 1, "18446744073709551616"},
+		{math.MaxInt64, math.MinInt64, 1, "18446744073709551616"},
+		{math.MinInt64, math.MaxInt64, math.MaxInt64, "3"},
+	}
+	for _, test := range tests {
+		seq, _ := NewInt(test.start, test.end, test.step)
+		if result := seq.Len().String(); result != test.expected {
+			t.Errorf("NewInt(%d, %d, %d).Len() is %s, expected %s", test.start, test.end, test.step, result, test.expected)
+		}
+	}
+}
+
+func TestIntContains(t *testing.T) {
+	up, _ := NewInt(1, 10, 3)
+	down, _ := NewInt(10, 1, 3)
+	wide, _ := NewInt(math.MinInt64, math.MaxInt64, 2)
+	tests := []struct {
+		seq      *Int
+		value    string
+		expected bool
+	}{
+		{up, "1", true},
+		{up, "7", true},
+		{up, "10", true},
+		{up, "5", false},
+		{up, "0", false},
+		{up, "13", false},
+		{up, "x", false},
+		{down, "10", true},
+		{down, "4", true},
+		{down, "5", false},
+		{down, "13", false},
+		{wide, "-9223372036854775808", true},
+		{wide, "9223372036854775806", true},
+		{wide, "9223372036854775807", false},
+		{wide, "9223372036854775808", false},
+	}
+	for _, test := range tests {
+		if result := test.seq.Contains(test.value); result != test.expected {
+			t.Errorf("%+v Contains(%q) is %t, expected %t", *test.seq, test.value, result, test.expected)
+		}
+	}
+}
+
+func TestAlpha(t *testing.T) {
+	tests := []struct {
+		start, end string
+		step       int
+		alphabet   string
+		expected   string
+	}{
+		{"A", "E", 2, Alphabet, "A,C,E"},
+		{"E", "A", 2, Alphabet, "E,C,A"},
+		{"Y", "AB", 1, Alphabet, "Y,Z,AA,AB"},
+		{"ZY", "AAB", 1, Alphabet, "ZY,ZZ,AAA,AAB"},
+		{"x", "ab", 1, Alphabet, "x,y,z,aa,ab"},
+		{"H98", "J01", 1, "ABCDEFGHJKLMNPQRSTUVWXYZ", "H98,H99,J00,J01"},
+		{"A9Z", "B0B", 1, Alphabet, "A9Z,B0A,B0B"},
+	}
+	for _, test := range tests {
+		seq, err := NewAlpha(test.start, test.end, test.step, test.alphabet)
+		if err != nil {
+			t.Errorf("NewAlpha(%q, %q) returned %s", test.start, test.end, err)
+			continue
+		}
+		if result := strings.Join(values(seq), ","); result != test.expected {
+			t.Errorf("NewAlpha(%q, %q) yields %s, expected %s", test.start, test.end, result, test.expected)
+		}
+	}
+	if _, err := NewAlpha("A", "I", 1, "ABCDEFGHJK"); err == nil {
+		t.Errorf("expected an error for a letter missing from the alphabet")
+	}
+	if _, err := NewAlpha("A1", "B", 1, Alphabet); err == nil {
+		t.Errorf("expected an error for values with different shapes")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	seq, err := NewDecimal(big.NewRat(0, 1), big.NewRat(1, 1), big.NewRat(1, 20), 2)
+	if err != nil {
+		t.Fatalf("NewDecimal(0, 1, 0.05) returned %s", err)
+	}
+	result := values(seq)
+	if len(result) != 21 {
+		t.Fatalf("expected 21 values, got %d: %s", len(result), strings.Join(result, ","))
+	}
+	for i, value := range result {
+		expected := new(big.Rat).Mul(big.NewRat(int64(i), 1), big.NewRat(1, 20)).FloatString(2)
+		if value != expected {
+			t.Errorf("value %d is %s, expected %s", i, value, expected)
+		}
+	}
+	for _, value := range []string{"0.35", "0.350", "1"} {
+		if seq.Contains(value) == false {
+			t.Errorf("expected %s in 0..1..0.05", value)
+		}
+	}
+	for _, value := range []string{"0.36", "1.05", "-0.05"} {
+		if seq.Contains(value) == true {
+			t.Errorf("didn't expect %s in 0..1..0.05", value)
+		}
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	start, _ := new(big.Int).SetString("18446744073709551614", 10)
+	end, _ := new(big.Int).SetString("18446744073709551618", 10)
+	seq, err := NewBigInt(start, end, big.NewInt(2))
+	if err != nil {
+		t.Fatalf("NewBigInt returned %s", err)
+	}
+	expected := "18446744073709551614,18446744073709551616,18446744073709551618"
+	if result := strings.Join(values(seq), ","); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+	if seq.Contains("18446744073709551616") == false {
+		t.Errorf("expected 2^64 in the sequence")
+	}
+	if seq.Contains("18446744073709551615") == true {
+		t.Errorf("didn't expect 2^64-1 in the sequence")
+	}
+	down, _ := NewBigInt(end, start, big.NewInt(2))
+	expected = "18446744073709551618,18446744073709551616,18446744073709551614"
+	if result := strings.Join(values(down), ","); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		start, end, step string
+		expected         string
+	}{
+		{"1", "3", "1", "1,2,3"},
+		{"0.5", "1.5", "0.5", "0.5,1.0,1.5"},
+		{"1", "1.5", "0.25", "1.00,1.25,1.50"},
+		{"a", "c", "1", "a,b,c"},
+		{"18446744073709551616", "18446744073709551617", "1", "18446744073709551616,18446744073709551617"},
+	}
+	for _, test := range tests {
+		seq, err := New(test.start, test.end, test.step, Alphabet)
+		if err != nil {
+			t.Errorf("New(%q, %q, %q) returned %s", test.start, test.end, test.step, err)
+			continue
+		}
+		if result := strings.Join(values(seq), ","); result != test.expected {
+			t.Errorf("New(%q, %q, %q) yields %s, expected %s", test.start, test.end, test.step, result, test.expected)
+		}
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"1", 0},
+		{"1.25", 2},
+		{"-0.5", 1},
+		{"1e-1", 1},
+		{"2.5E-3", 4},
+		{"1e2", 0},
+		{"1.25e1", 1},
+		{"1.5e+3", 0},
+	}
+	for _, test := range tests {
+		if result := DecimalPlaces(test.value); result != test.expected {
+			t.Errorf("DecimalPlaces(%q) is %d, expected %d", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestIterator(t *testing.T) {
+	seq, _ := NewInt(3, 1, 1)
+	it := NewIterator(seq)
+	for k, expected := range []string{"3", "2", "1"} {
+		if it.Next() == false {
+			t.Fatalf("Next() stopped after %d values", k)
+		}
+		index := it.Index()
+		if index.Int64() != int64(k) {
+			t.Errorf("Index() is %s, expected %d", index, k)
+		}
+		// Index returns a copy the caller may change
+		index.SetInt64(100)
+		if it.Value() != expected {
+			t.Errorf("Value() is %s, expected %s", it.Value(), expected)
+		}
+		if it.Raw() != seq.Raw(big.NewInt(int64(k))) {
+			t.Errorf("Raw() is %v, expected %v", it.Raw(), seq.Raw(big.NewInt(int64(k))))
+		}
+	}
+	if it.Next() == true {
+		t.Errorf("Next() continued past the end")
+	}
+}
@@ -0,0 +1,276 @@
+//
+// set.go - sets of integers held as merged intervals.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Interval is the integers from First to Last, both included
+type Interval struct {
+	First int
+	Last  int
+}
+
+// Set is a set of integers held as sorted intervals that neither
+// overlap nor touch, so very large ranges need little memory. A Set is
+// also a Sequence of its integers in increasing order.
+type Set struct {
+	intervals []Interval
+	// offsets holds the number of integers before each interval. There
+	// is always a gap before an interval so they fit in a uint64.
+	offsets []uint64
+}
+
+// NewSet returns the set of integers in the intervals given
+func NewSet(intervals []Interval) *Set {
+	sorted := []Interval{}
+	for _, iv := range intervals {
+		if iv.First > iv.Last {
+			iv.First, iv.Last = iv.Last, iv.First
+		}
+		sorted = append(sorted, iv)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].First < sorted[j].First })
+	s := &Set{}
+	for _, iv := range sorted {
+		n := len(s.intervals)
+		// Merge intervals that overlap or touch, taking care at the
+		// largest int
+		if n > 0 && (iv.First <= s.intervals[n-1].Last || iv.First-1 == s.intervals[n-1].Last) {
+			if iv.Last > s.intervals[n-1].Last {
+				s.intervals[n-1].Last = iv.Last
+			}
+			continue
+		}
+		s.intervals = append(s.intervals, iv)
+	}
+	count := uint64(0)
+	for _, iv := range s.intervals {
+		s.offsets = append(s.offsets, count)
+		count += uint64(iv.Last) - uint64(iv.First) + 1
+	}
+	return s
+}
+
+// setPoints is the most integers SetOf reads one by one, sequences
+// that don't count by one are held as an interval per integer.
+const setPoints = 1 << 20
+
+// SetOf returns the integers of seq as a set. Int sequences counting
+// by one are read as a single interval, other sequences value by value
+// up to a million or so integers.
+func SetOf(seq Sequence) (*Set, error) {
+	switch r := seq.(type) {
+	case *Int:
+		if r.Step == 1 || r.Step == -1 {
+			return NewSet([]Interval{{r.Start, r.End}}), nil
+		}
+	case *BigInt:
+		return nil, fmt.Errorf("%s to %s is beyond the integers a set can hold", r.At(new(big.Int)), r.At(new(big.Int).Sub(r.Len(), big.NewInt(1))))
+	case *Numeral:
+		return SetOf(r.Sequence)
+	case *padded:
+		return SetOf(r.Sequence)
+	case Concat:
+		intervals := []Interval{}
+		for _, part := range r {
+			s, err := SetOf(part)
+			if err != nil {
+				return nil, err
+			}
+			intervals = append(intervals, s.intervals...)
+		}
+		return NewSet(intervals), nil
+	}
+	if seq.Len().Cmp(big.NewInt(setPoints)) > 0 {
+		return nil, fmt.Errorf("%s separate values are too many for a set, the limit is %d", seq.Len(), setPoints)
+	}
+	intervals := []Interval{}
+	for it := NewIterator(seq); it.Next(); {
+		v, err := strconv.Atoi(it.Value())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer, sets hold integers", it.Value())
+		}
+		intervals = append(intervals, Interval{v, v})
+	}
+	return NewSet(intervals), nil
+}
+
+// Set returns the integers of the expression with the excluded
+// segments removed
+func (e *Expression) Set() (*Set, error) {
+	include, exclude := []Interval{}, []Interval{}
+	for _, seg := range e.Segments {
+		s, err := SetOf(seg.seq)
+		if err != nil {
+			return nil, err
+		}
+		if seg.Exclude == true {
+			exclude = append(exclude, s.intervals...)
+		} else {
+			include = append(include, s.intervals...)
+		}
+	}
+	return NewSet(include).Difference(NewSet(exclude)), nil
+}
+
+// Intervals returns the set's intervals in increasing order
+func (s *Set) Intervals() []Interval {
+	return append([]Interval{}, s.intervals...)
+}
+
+// Union returns the integers in either set
+func (s *Set) Union(t *Set) *Set {
+	return NewSet(append(s.Intervals(), t.intervals...))
+}
+
+// Intersect returns the integers in both sets
+func (s *Set) Intersect(t *Set) *Set {
+	out := []Interval{}
+	for i, j := 0, 0; i < len(s.intervals) && j < len(t.intervals); {
+		a, b := s.intervals[i], t.intervals[j]
+		first, last := a.First, a.Last
+		if b.First > first {
+			first = b.First
+		}
+		if b.Last < last {
+			last = b.Last
+		}
+		if first <= last {
+			out = append(out, Interval{first, last})
+		}
+		if a.Last < b.Last {
+			i++
+		} else {
+			j++
+		}
+	}
+	return NewSet(out)
+}
+
+// Difference returns the integers in s that aren't in t
+func (s *Set) Difference(t *Set) *Set {
+	out := []Interval{}
+	j := 0
+	for _, a := range s.intervals {
+		first := a.First
+		for j < len(t.intervals) && t.intervals[j].Last < first {
+			j++
+		}
+		k, done := j, false
+		for ; k < len(t.intervals) && t.intervals[k].First <= a.Last; k++ {
+			b := t.intervals[k]
+			if b.First > first {
+				out = append(out, Interval{first, b.First - 1})
+			}
+			if b.Last >= a.Last {
+				done = true
+				break
+			}
+			first = b.Last + 1
+		}
+		if done == false {
+			out = append(out, Interval{first, a.Last})
+		}
+	}
+	return NewSet(out)
+}
+
+// Gaps returns the integers missing between the smallest and largest
+// integers of the set, its complement within its own span
+func (s *Set) Gaps() *Set {
+	out := []Interval{}
+	for i := 1; i < len(s.intervals); i++ {
+		out = append(out, Interval{s.intervals[i-1].Last + 1, s.intervals[i].First - 1})
+	}
+	return NewSet(out)
+}
+
+// String writes the set compactly as FIRST-LAST intervals and single
+// integers separated by commas, e.g. "1-40,45,50-99"
+func (s *Set) String() string {
+	parts := []string{}
+	for _, iv := range s.intervals {
+		if iv.First == iv.Last {
+			parts = append(parts, strconv.Itoa(iv.First))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", iv.First, iv.Last))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Len returns the number of integers in the set
+func (s *Set) Len() *big.Int {
+	n := len(s.intervals)
+	if n == 0 {
+		return new(big.Int)
+	}
+	last := s.intervals[n-1]
+	count := new(big.Int).SetUint64(s.offsets[n-1])
+	count.Add(count, new(big.Int).SetUint64(uint64(last.Last)-uint64(last.First)))
+	return count.Add(count, big.NewInt(1))
+}
+
+// value returns the integer at index i of the set
+func (s *Set) value(i *big.Int) int {
+	k := i.Uint64()
+	// The last interval starting at or before index k holds it
+	j := sort.Search(len(s.offsets), func(j int) bool { return s.offsets[j] > k }) - 1
+	return int(uint64(s.intervals[j].First) + (k - s.offsets[j]))
+}
+
+// skip returns the j-th (counting from 0) integer from zero up that
+// isn't in the set, the set holding no negative integers
+func (s *Set) skip(j uint64) uint64 {
+	// Intervals with no more than j integers missing before them are
+	// passed over
+	m := sort.Search(len(s.intervals), func(m int) bool { return uint64(s.intervals[m].First)-s.offsets[m] > j })
+	if m == 0 {
+		return j
+	}
+	iv := s.intervals[m-1]
+	return j + s.offsets[m-1] + uint64(iv.Last) - uint64(iv.First) + 1
+}
+
+// At returns the integer at index i
+func (s *Set) At(i *big.Int) string {
+	return strconv.Itoa(s.value(i))
+}
+
+// Raw returns the integer at index i as an int
+func (s *Set) Raw(i *big.Int) interface{} {
+	return s.value(i)
+}
+
+// Contains reports if value is an integer in the set
+func (s *Set) Contains(value string) bool {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	j := sort.Search(len(s.intervals), func(j int) bool { return s.intervals[j].Last >= v })
+	return j < len(s.intervals) && s.intervals[j].First <= v
+}
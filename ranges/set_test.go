@@ -0,0 +1,97 @@
+//
+// set_test.go - tests for integer sets.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"testing"
+)
+
+func TestSetOf(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"1..10", "1-10"},
+		{"10..1", "1-10"},
+		{"1..10..3,5", "1,4-5,7,10"},
+		{"001..010", "1-10"},
+		{"0x1..0x3,7", "1-3,7"},
+		{"1..1000000000000,!5..9", "1-4,10-1000000000000"},
+		{"1..3,!1..3", ""},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %s", test.expr, err)
+			continue
+		}
+		s, err := e.Set()
+		if err != nil {
+			t.Errorf("%q Set() returned %s", test.expr, err)
+			continue
+		}
+		if result := s.String(); result != test.expected {
+			t.Errorf("%q Set() is %q, expected %q", test.expr, result, test.expected)
+		}
+	}
+	// Sets that can't be held
+	for _, expr := range []string{"a..c", "1.5..3", "1..100000000000000000000", "1..1000000000..3"} {
+		e, err := Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %s", expr, err)
+			continue
+		}
+		if _, err := e.Set(); err == nil {
+			t.Errorf("expected an error for the set of %q", expr)
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := NewSet([]Interval{{1, 10}, {20, 30}})
+	b := NewSet([]Interval{{5, 25}})
+	tests := []struct {
+		name     string
+		set      *Set
+		expected string
+	}{
+		{"union", a.Union(b), "1-30"},
+		{"intersection", a.Intersect(b), "5-10,20-25"},
+		{"difference", a.Difference(b), "1-4,26-30"},
+		{"gaps", a.Gaps(), "11-19"},
+		{"empty difference", a.Difference(a), ""},
+		{"empty gaps", b.Gaps(), ""},
+	}
+	for _, test := range tests {
+		if result := test.set.String(); result != test.expected {
+			t.Errorf("%s is %q, expected %q", test.name, result, test.expected)
+		}
+	}
+	empty := a.Difference(a)
+	if empty.Len().Sign() != 0 {
+		t.Errorf("expected an empty set, Len() is %s", empty.Len())
+	}
+	if empty.Contains("1") == true {
+		t.Errorf("didn't expect 1 in an empty set")
+	}
+	if len(values(empty)) != 0 {
+		t.Errorf("expected no values from an empty set")
+	}
+}
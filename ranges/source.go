@@ -0,0 +1,154 @@
+//
+// source.go - sequences read from text lines and JSON, and slices of them.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ReadLines reads text returning the line numbers (from 1) and the
+// lines without their line endings
+func ReadLines(r io.Reader) (List, List, error) {
+	numbers, lines := List{}, List{}
+	in := bufio.NewReader(r)
+	for {
+		line, err := in.ReadString('\n')
+		if line != "" {
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+			numbers = append(numbers, strconv.Itoa(len(lines)))
+		}
+		if err == io.EOF {
+			return numbers, lines, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// ReadJSON reads a JSON array or object returning its indexes (from 0)
+// or keys, in the order they appear, and its values as compact JSON
+func ReadJSON(r io.Reader) (List, List, error) {
+	keys, values := List{}, List{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if ok == false || (delim != '[' && delim != '{') {
+		return nil, nil, fmt.Errorf("expected a JSON array or object")
+	}
+	for dec.More() == true {
+		key := strconv.Itoa(len(keys))
+		if delim == '{' {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key = t.(string)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, buf.String())
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+// slice is the values of a sequence at the positions of an Int sequence
+type slice struct {
+	seq   Sequence
+	index *Int
+}
+
+// Slice returns the values of seq from position start to end by step,
+// both included, like a range it counts down when start is after end.
+// Positions count from 0, negative positions count back from the end
+// (-1 is the last value).
+func Slice(seq Sequence, start, end, step int) (Sequence, error) {
+	n := seq.Len()
+	if n.IsInt64() == false {
+		return nil, fmt.Errorf("%s values are too many to slice", n)
+	}
+	positions := []int{}
+	for _, given := range []int{start, end} {
+		p := given
+		if p < 0 {
+			p += int(n.Int64())
+		}
+		if p < 0 || int64(p) >= n.Int64() {
+			return nil, fmt.Errorf("position %d is outside the %s values", given, n)
+		}
+		positions = append(positions, p)
+	}
+	index, err := NewInt(positions[0], positions[1], step)
+	if err != nil {
+		return nil, err
+	}
+	return &slice{seq: seq, index: index}, nil
+}
+
+// Len returns the number of values
+func (s *slice) Len() *big.Int {
+	return s.index.Len()
+}
+
+// position returns the index into the sliced sequence of value i
+func (s *slice) position(i *big.Int) *big.Int {
+	return big.NewInt(int64(s.index.value(i)))
+}
+
+// At returns the value at index i
+func (s *slice) At(i *big.Int) string {
+	return s.seq.At(s.position(i))
+}
+
+// Raw returns the value at index i for use with printf verbs
+func (s *slice) Raw(i *big.Int) interface{} {
+	return s.seq.Raw(s.position(i))
+}
+
+// Contains reports if value is one of the values in the slice
+func (s *slice) Contains(value string) bool {
+	for it := NewIterator(s); it.Next(); {
+		if it.Value() == value {
+			return true
+		}
+	}
+	return false
+}
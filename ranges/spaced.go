@@ -0,0 +1,333 @@
+//
+// spaced.go - evenly spaced, geometric and logarithmic sequences
+// with rounding controls.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package ranges
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Rounding says how a value is rounded to the decimal places written
+type Rounding int
+
+const (
+	// RoundNearest rounds halves away from zero
+	RoundNearest Rounding = iota
+	// RoundEven rounds halves to the even neighbour
+	RoundEven
+	// RoundDown rounds toward negative infinity
+	RoundDown
+	// RoundUp rounds toward positive infinity
+	RoundUp
+)
+
+// ParseRounding converts "nearest", "even", "down" or "up" to a Rounding
+func ParseRounding(s string) (Rounding, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "nearest", "":
+		return RoundNearest, nil
+	case "even":
+		return RoundEven, nil
+	case "down":
+		return RoundDown, nil
+	case "up":
+		return RoundUp, nil
+	}
+	return RoundNearest, fmt.Errorf("rounding must be nearest, even, down or up, got %q", s)
+}
+
+// Round returns v rounded to places digits after the decimal point
+func (m Rounding) Round(v *big.Rat, places int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	x := new(big.Rat).Mul(v, new(big.Rat).SetInt(scale))
+	// q is floor(x), denominators are always positive
+	q := new(big.Int).Div(x.Num(), x.Denom())
+	frac := new(big.Rat).Sub(x, new(big.Rat).SetInt(q))
+	up := false
+	switch m {
+	case RoundUp:
+		up = frac.Sign() > 0
+	case RoundDown:
+		up = false
+	default:
+		switch frac.Cmp(big.NewRat(1, 2)) {
+		case 1:
+			up = true
+		case 0:
+			if m == RoundEven {
+				up = q.Bit(0) == 1
+			} else {
+				up = x.Sign() > 0
+			}
+		}
+	}
+	if up == true {
+		q.Add(q, big.NewInt(1))
+	}
+	return new(big.Rat).SetFrac(q, scale)
+}
+
+// Format writes v rounded to places digits after the decimal point
+func (m Rounding) Format(v *big.Rat, places int) string {
+	return m.Round(v, places).FloatString(places)
+}
+
+// raw returns v rounded for use with printf verbs, an int (or
+// *big.Int) without decimal places otherwise a float64
+func (m Rounding) raw(v *big.Rat, places int) interface{} {
+	r := m.Round(v, places)
+	if places == 0 {
+		if r.Num().IsInt64() == true && int64(int(r.Num().Int64())) == r.Num().Int64() {
+			return int(r.Num().Int64())
+		}
+		return r.Num()
+	}
+	f, _ := r.Float64()
+	return f
+}
+
+// Linear is Len values evenly spaced from a start to an end, both
+// included. Values are written with Places decimal places (integers
+// when Places is zero) rounded using Rounding.
+type Linear struct {
+	*progression
+	Places   int
+	Rounding Rounding
+}
+
+// NewLinear returns count values evenly spaced from start to end
+func NewLinear(start, end *big.Rat, count int64, places int, rounding Rounding) (*Linear, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("count must be at least one")
+	}
+	step := new(big.Rat)
+	if count > 1 {
+		step.Sub(end, start)
+		step.Quo(step, big.NewRat(count-1, 1))
+	}
+	p := &progression{first: start, step: step, count: big.NewInt(count)}
+	return &Linear{progression: p, Places: places, Rounding: rounding}, nil
+}
+
+// At returns the value at index i
+func (r *Linear) At(i *big.Int) string {
+	return r.Rounding.Format(r.nth(i), r.Places)
+}
+
+// Raw returns the value at index i as an int or float64
+func (r *Linear) Raw(i *big.Int) interface{} {
+	return r.Rounding.raw(r.nth(i), r.Places)
+}
+
+// Contains reports if value is one of the sequence's values as written
+func (r *Linear) Contains(value string) bool {
+	v, ok := new(big.Rat).SetString(value)
+	if ok == false {
+		return false
+	}
+	if r.step.Sign() == 0 {
+		return r.Rounding.Round(r.first, r.Places).Cmp(v) == 0
+	}
+	// The nearest index, its neighbours may round to the value too
+	x := new(big.Rat).Sub(v, r.first)
+	x.Quo(x, r.step)
+	near := new(big.Int).Quo(x.Num(), x.Denom())
+	return nearby(r, near, v, r.Places, r.Rounding)
+}
+
+// nearby reports if one of the values at indexes next to i is v
+func nearby(seq Sequence, i *big.Int, v *big.Rat, places int, rounding Rounding) bool {
+	n := seq.Len()
+	want := rounding.Format(v, places)
+	for d := int64(-1); d <= 1; d++ {
+		j := new(big.Int).Add(i, big.NewInt(d))
+		if j.Sign() >= 0 && j.Cmp(n) < 0 && seq.At(j) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// floatPrec is the precision, in bits, geometric values are computed with
+const floatPrec = 256
+
+// Geometric is a start multiplied by a ratio for each following value.
+// Values are written with Places decimal places (integers when Places
+// is zero) rounded using Rounding.
+type Geometric struct {
+	first    *big.Float
+	ratio    *big.Float
+	count    *big.Int
+	Places   int
+	Rounding Rounding
+}
+
+// pow returns x raised to the power n by repeated squaring
+func pow(x *big.Float, n *big.Int) *big.Float {
+	result := new(big.Float).SetPrec(floatPrec).SetInt64(1)
+	sq := new(big.Float).SetPrec(floatPrec).Set(x)
+	for i := 0; i < n.BitLen(); i++ {
+		if n.Bit(i) == 1 {
+			result.Mul(result, sq)
+		}
+		sq.Mul(sq, sq)
+	}
+	return result
+}
+
+// log2 approximates the base 2 logarithm of a positive x
+func log2(x *big.Float) float64 {
+	mant := new(big.Float)
+	exp := x.MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
+
+func (r *Geometric) nth(i *big.Int) *big.Rat {
+	v := pow(r.ratio, i)
+	v.Mul(v, r.first)
+	rat, _ := v.Rat(nil)
+	return rat
+}
+
+// NewGeometric returns start, start * ratio, start * ratio^2 ... up to
+// end. Like an increment the ratio is inverted when it moves away from
+// end, start and end must be non-zero with the same sign.
+func NewGeometric(start, end, ratio *big.Rat, places int, rounding Rounding) (*Geometric, error) {
+	if start.Sign() == 0 || start.Sign() != end.Sign() {
+		return nil, fmt.Errorf("start and end must be non-zero with the same sign")
+	}
+	if ratio.Sign() <= 0 || ratio.Cmp(big.NewRat(1, 1)) == 0 {
+		return nil, fmt.Errorf("ratio must be greater than zero and not one")
+	}
+	q := new(big.Rat).Quo(end, start)
+	if (q.Cmp(big.NewRat(1, 1)) >= 0) != (ratio.Cmp(big.NewRat(1, 1)) > 0) {
+		ratio = new(big.Rat).Inv(ratio)
+	}
+	r := &Geometric{
+		first:    new(big.Float).SetPrec(floatPrec).SetRat(start),
+		ratio:    new(big.Float).SetPrec(floatPrec).SetRat(ratio),
+		Places:   places,
+		Rounding: rounding,
+	}
+	// Estimate the count from logarithms then check it against end.
+	// Values within a tiny fraction of end, from rounding in the
+	// ratio, count as reaching it.
+	estimate := math.Floor(log2(new(big.Float).SetRat(q)) / log2(r.ratio))
+	if estimate < 0 || math.IsNaN(estimate) {
+		estimate = 0
+	}
+	increasing := end.Cmp(start) >= 0
+	tolerance := new(big.Rat).Abs(end)
+	tolerance.Mul(tolerance, new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), floatPrec-32)))
+	within := func(i *big.Int) bool {
+		v := r.nth(i)
+		if c := v.Cmp(end); (increasing == true && c <= 0) || (increasing == false && c >= 0) {
+			return true
+		}
+		return v.Sub(v, end).Abs(v).Cmp(tolerance) <= 0
+	}
+	n := big.NewInt(int64(estimate))
+	one := big.NewInt(1)
+	for n.Sign() > 0 && within(n) == false {
+		n.Sub(n, one)
+	}
+	for within(new(big.Int).Add(n, one)) == true {
+		n.Add(n, one)
+	}
+	r.count = n.Add(n, one)
+	return r, nil
+}
+
+// NewLogarithmic returns count values from start to end evenly spaced
+// on a logarithmic scale, a geometric sequence whose ratio is found
+// from the count.
+func NewLogarithmic(start, end *big.Rat, count int64, places int, rounding Rounding) (*Geometric, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("count must be at least one")
+	}
+	if start.Sign() == 0 || start.Sign() != end.Sign() {
+		return nil, fmt.Errorf("start and end must be non-zero with the same sign")
+	}
+	r := &Geometric{
+		first:    new(big.Float).SetPrec(floatPrec).SetRat(start),
+		ratio:    new(big.Float).SetPrec(floatPrec).SetInt64(1),
+		count:    big.NewInt(count),
+		Places:   places,
+		Rounding: rounding,
+	}
+	if count == 1 {
+		return r, nil
+	}
+	// The ratio is the (count - 1)th root of end / start, refined from a
+	// float64 estimate with Newton's method.
+	q := new(big.Float).SetPrec(floatPrec).SetRat(new(big.Rat).Quo(end, start))
+	m := big.NewInt(count - 1)
+	r.ratio.SetFloat64(math.Exp2(log2(q) / float64(count-1)))
+	mf := new(big.Float).SetPrec(floatPrec).SetInt(m)
+	for k := 0; k < 64; k++ {
+		p := pow(r.ratio, new(big.Int).Sub(m, big.NewInt(1)))
+		delta := new(big.Float).SetPrec(floatPrec).Mul(p, r.ratio)
+		delta.Sub(delta, q)
+		delta.Quo(delta, new(big.Float).SetPrec(floatPrec).Mul(mf, p))
+		r.ratio.Sub(r.ratio, delta)
+		if delta.Sign() == 0 || delta.MantExp(nil)-r.ratio.MantExp(nil) < -floatPrec+8 {
+			break
+		}
+	}
+	return r, nil
+}
+
+// Len returns the number of values
+func (r *Geometric) Len() *big.Int {
+	return new(big.Int).Set(r.count)
+}
+
+// At returns the value at index i
+func (r *Geometric) At(i *big.Int) string {
+	return r.Rounding.Format(r.nth(i), r.Places)
+}
+
+// Raw returns the value at index i as an int or float64
+func (r *Geometric) Raw(i *big.Int) interface{} {
+	return r.Rounding.raw(r.nth(i), r.Places)
+}
+
+// Contains reports if value is one of the sequence's values as written
+func (r *Geometric) Contains(value string) bool {
+	v, ok := new(big.Rat).SetString(value)
+	if ok == false || v.Sign() == 0 || v.Sign() != r.first.Sign() {
+		return false
+	}
+	if r.ratio.Cmp(big.NewFloat(1)) == 0 {
+		return r.At(new(big.Int)) == r.Rounding.Format(v, r.Places)
+	}
+	// The nearest index from logarithms, its neighbours are checked too
+	x := new(big.Float).Quo(new(big.Float).SetRat(v), r.first)
+	near := math.Floor(log2(x)/log2(r.ratio) + 0.5)
+	if near < 0 || math.IsNaN(near) || math.IsInf(near, 0) {
+		near = 0
+	}
+	i, _ := new(big.Float).SetFloat64(near).Int(nil)
+	return nearby(r, i, v, r.Places, r.Rounding)
+}
@@ -0,0 +1,88 @@
+//
+// check.go - find times that parse but are probably wrong.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// time.Parse accepts a fraction after the seconds even when the
+	// layout doesn't have one
+	extraFraction = regexp.MustCompile(`(\d\d)[.,]\d+`)
+	// fields are the runs of digits or letters in a time string
+	fields = regexp.MustCompile(`\d+|\pL+`)
+)
+
+// sameFields compares the fields of two time strings, numbers by
+// value so "01" and "1" match, names ignoring case.
+func sameFields(a, b string) bool {
+	x, y := fields.FindAllString(a, -1), fields.FindAllString(b, -1)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if strings.TrimLeft(x[i], "0") != strings.TrimLeft(y[i], "0") && strings.EqualFold(x[i], y[i]) == false {
+			return false
+		}
+	}
+	return true
+}
+
+// sameText compares a value with its formatted time field by field,
+// ignoring fractional seconds when the layout has none.
+func sameText(layout, formatted, value string) bool {
+	if sameFields(formatted, value) {
+		return true
+	}
+	for _, t := range splitLayout(layout) {
+		if isFraction(t.Text) {
+			return false
+		}
+	}
+	return sameFields(formatted, extraFraction.ReplaceAllString(value, "$1"))
+}
+
+// Suspicious returns the reasons a time t, parsed from value using
+// layout, may not be what the value meant. Go reads some values
+// without complaint, a weekday that doesn't match the date is ignored
+// and an unknown zone abbreviation is given a zero offset. An empty
+// list means nothing looks wrong.
+func Suspicious(layout, value string, t time.Time) []string {
+	problems := []string{}
+	// Offsets of zero may be written as +00:00 rather than Z
+	numeric := strings.NewReplacer("Z07:00", "-07:00", "Z0700", "-0700", "Z07", "-07").Replace(layout)
+	formatted := t.Format(layout)
+	if !sameText(layout, formatted, value) && !sameText(numeric, t.Format(numeric), value) {
+		problems = append(problems, fmt.Sprintf("reads as %q, parts of the value are inconsistent or were ignored", formatted))
+	}
+	for _, token := range splitLayout(layout) {
+		if token.Text == "MST" {
+			name, offset := t.Zone()
+			if offset == 0 && name != "UTC" && name != "GMT" && name != "Z" {
+				problems = append(problems, fmt.Sprintf("time zone %q is unknown, its offset was taken as +0000", name))
+			}
+		}
+	}
+	return problems
+}
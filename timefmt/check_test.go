@@ -0,0 +1,56 @@
+//
+// check_test.go - tests for spotting suspicious time values.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuspicious(t *testing.T) {
+	tests := []struct {
+		layout     string
+		value      string
+		suspicious bool
+	}{
+		{"1/2/2006", "7/2/2016", false},
+		{"1/2/2006", "07/02/2016", false},
+		{"1/2/2006 3:04 PM", "7/2/2016 08:08 AM", false},
+		{"_2 Jan 2006", " 2 Jul 2016", false},
+		{"2006-01-02T15:04:05Z07:00", "2016-07-02T08:08:08+00:00", false},
+		{"2006-01-02T15:04:05Z07:00", "2016-07-02T08:08:08.5Z", false},
+		{"Mon, 2006-01-02", "Sat, 2016-07-02", false},
+		{"Mon, 2006-01-02", "Mon, 2016-07-02", true},
+		{"Monday 1/2/2006", "Friday 7/2/2016", true},
+		{"2006-01-02 15:04 MST", "2016-07-02 08:08 XYZ", true},
+	}
+	for _, test := range tests {
+		ts, err := time.Parse(test.layout, test.value)
+		if err != nil {
+			t.Errorf("can't parse %q with %q, %s", test.value, test.layout, err)
+			continue
+		}
+		problems := Suspicious(test.layout, test.value, ts)
+		if (len(problems) > 0) != test.suspicious {
+			t.Errorf("%q with %q, expected suspicious %t, got %q", test.value, test.layout, test.suspicious, problems)
+		}
+	}
+}
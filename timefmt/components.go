@@ -0,0 +1,81 @@
+//
+// components.go - break a time into its calendar fields in one step.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"time"
+)
+
+// Components holds the calendar fields of a time. Weekday counts
+// from Sunday (0) like Go, ISOWeekday from Monday (1) to Sunday (7).
+type Components struct {
+	Timestamp   string `json:"timestamp"`
+	Year        int    `json:"year"`
+	Quarter     int    `json:"quarter"`
+	Month       int    `json:"month"`
+	MonthName   string `json:"month_name"`
+	Day         int    `json:"day"`
+	DayOfYear   int    `json:"day_of_year"`
+	Weekday     int    `json:"weekday"`
+	ISOWeekday  int    `json:"iso_weekday"`
+	WeekdayName string `json:"weekday_name"`
+	ISOYear     int    `json:"iso_year"`
+	ISOWeek     int    `json:"iso_week"`
+	Hour        int    `json:"hour"`
+	Minute      int    `json:"minute"`
+	Second      int    `json:"second"`
+	Nanosecond  int    `json:"nanosecond"`
+	Zone        string `json:"zone"`
+	Offset      int    `json:"offset"`
+	Epoch       int64  `json:"epoch"`
+	EpochMilli  int64  `json:"epoch_milli"`
+}
+
+// NewComponents returns the calendar fields of t in t's location
+func NewComponents(t time.Time) *Components {
+	isoYear, isoWeek := t.ISOWeek()
+	isoWeekday := int(t.Weekday())
+	if isoWeekday == 0 {
+		isoWeekday = 7
+	}
+	zone, offset := t.Zone()
+	return &Components{
+		Timestamp:   t.Format(time.RFC3339Nano),
+		Year:        t.Year(),
+		Quarter:     (int(t.Month())-1)/3 + 1,
+		Month:       int(t.Month()),
+		MonthName:   t.Month().String(),
+		Day:         t.Day(),
+		DayOfYear:   t.YearDay(),
+		Weekday:     int(t.Weekday()),
+		ISOWeekday:  isoWeekday,
+		WeekdayName: t.Weekday().String(),
+		ISOYear:     isoYear,
+		ISOWeek:     isoWeek,
+		Hour:        t.Hour(),
+		Minute:      t.Minute(),
+		Second:      t.Second(),
+		Nanosecond:  t.Nanosecond(),
+		Zone:        zone,
+		Offset:      offset,
+		Epoch:       t.Unix(),
+		EpochMilli:  t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond),
+	}
+}
@@ -0,0 +1,53 @@
+//
+// components_test.go - tests for breaking a time into its calendar fields.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComponentsEpoch(t *testing.T) {
+	tests := []struct {
+		value      string
+		epoch      int64
+		epochMilli int64
+	}{
+		{"2016-07-02T08:08:08.123Z", 1467446888, 1467446888123},
+		{"1970-01-01T00:00:00Z", 0, 0},
+		{"1969-12-31T23:59:59.5Z", -1, -500},
+		{"1500-01-01T00:00:00Z", -14831769600, -14831769600000},
+		{"2300-01-01T00:00:00.001Z", 10413792000, 10413792000001},
+	}
+	for _, test := range tests {
+		ts, err := time.Parse(time.RFC3339Nano, test.value)
+		if err != nil {
+			t.Fatalf("can't parse %s, %s", test.value, err)
+		}
+		c := NewComponents(ts)
+		if c.Epoch != test.epoch {
+			t.Errorf("%s epoch is %d, expected %d", test.value, c.Epoch, test.epoch)
+		}
+		if c.EpochMilli != test.epochMilli {
+			t.Errorf("%s epoch_milli is %d, expected %d", test.value, c.EpochMilli, test.epochMilli)
+		}
+	}
+}
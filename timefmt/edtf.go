@@ -0,0 +1,642 @@
+//
+// edtf.go - parse and validate Extended Date/Time Format (EDTF) strings,
+// Levels 0 through 2, as described at https://www.loc.gov/standards/datetime/
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Qualifier records the EDTF uncertain ("?"), approximate ("~") or
+// both ("%") markers applied to a date or date component.
+type Qualifier int
+
+const (
+	// Uncertain is marked with "?"
+	Uncertain Qualifier = 1 << iota
+	// Approximate is marked with "~"
+	Approximate
+	// UncertainApproximate is marked with "%"
+	UncertainApproximate = Uncertain | Approximate
+)
+
+// EDTFKind describes the shape of a parsed EDTF string
+type EDTFKind int
+
+const (
+	// EDTFSingle is a single date or date and time (e.g. 1984?, 2004-06-11)
+	EDTFSingle EDTFKind = iota
+	// EDTFInterval is a start and end date separated by "/" (e.g. 1964/2008)
+	EDTFInterval
+	// EDTFOneOf is a set where one member applies (e.g. [1667,1668,1670..1672])
+	EDTFOneOf
+	// EDTFAllOf is a set where all members apply (e.g. {1960,1961})
+	EDTFAllOf
+)
+
+// EDTFDate is a single date from an EDTF string. Month holds the
+// season code (21 - 41) when a season is given. Components that are
+// absent or contain unspecified digits (X) are zero.
+type EDTFDate struct {
+	Year    int
+	Month   int
+	Day     int
+	Hour    int
+	Minute  int
+	Second  int
+	HasTime bool
+
+	YearQualifier  Qualifier
+	MonthQualifier Qualifier
+	DayQualifier   Qualifier
+
+	Unspecified       bool
+	SignificantDigits int
+
+	// Earliest and Latest are the first and last instants the date covers
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// EDTF is the result of parsing an EDTF string. Start and End are set
+// for single dates (both the same date) and intervals (nil when the
+// end is open or unknown). Members holds the elements of a set, each
+// either a single date or a ".." range.
+type EDTF struct {
+	Source  string
+	Level   int
+	Kind    EDTFKind
+	Start   *EDTFDate
+	End     *EDTFDate
+	Members []*EDTF
+
+	// OpenStart and OpenEnd are true for ".." ends, UnknownStart and
+	// UnknownEnd for empty interval ends.
+	OpenStart    bool
+	OpenEnd      bool
+	UnknownStart bool
+	UnknownEnd   bool
+
+	// Earliest and Latest bound the whole expression, they are zero
+	// when the corresponding end is open or unknown.
+	Earliest time.Time
+	Latest   time.Time
+}
+
+var (
+	edtfDateTime = regexp.MustCompile(`^(-?\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2}):(\d{2})(Z|[+-]\d{2}(?::?\d{2})?)?$`)
+	edtfLongYear = regexp.MustCompile(`^Y(-?)(\d+)(?:E(\d+))?(?:S(\d+))?$`)
+	edtfSigYear  = regexp.MustCompile(`^(-?)(\d{4})S(\d+)$`)
+	edtfDate     = regexp.MustCompile(`^([?~%]?)(-?[0-9X]{4})([?~%]?)(?:-([?~%]?)([0-9X]{2})([?~%]?)(?:-([?~%]?)([0-9X]{2})([?~%]?))?)?$`)
+	edtfLevel1X  = regexp.MustCompile(`^\d{2}(\d{2}|\dX|XX)$`)
+)
+
+// seasonMonths maps EDTF season codes to their first month and
+// length in months. Codes 21 - 24 are treated as northern hemisphere.
+var seasonMonths = map[int][2]int{
+	21: {3, 3}, 22: {6, 3}, 23: {9, 3}, 24: {12, 3},
+	25: {3, 3}, 26: {6, 3}, 27: {9, 3}, 28: {12, 3},
+	29: {9, 3}, 30: {12, 3}, 31: {3, 3}, 32: {6, 3},
+	33: {1, 3}, 34: {4, 3}, 35: {7, 3}, 36: {10, 3},
+	37: {1, 4}, 38: {5, 4}, 39: {9, 4},
+	40: {1, 6}, 41: {7, 6},
+}
+
+func qualifierOf(s string) Qualifier {
+	switch s {
+	case "?":
+		return Uncertain
+	case "~":
+		return Approximate
+	case "%":
+		return UncertainApproximate
+	}
+	return 0
+}
+
+// String renders a qualifier in EDTF notation
+func (q Qualifier) String() string {
+	switch q {
+	case Uncertain:
+		return "?"
+	case Approximate:
+		return "~"
+	case UncertainApproximate:
+		return "%"
+	}
+	return ""
+}
+
+// daysIn returns the number of days in a month of a given year
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// matchDigits reports if the two digit value n fits a pattern
+// where X matches any digit.
+func matchDigits(pattern string, n int) bool {
+	s := fmt.Sprintf("%02d", n)
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != 'X' && pattern[i] != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// yearBounds returns the smallest and largest year matching a four
+// digit year pattern that may contain X digits.
+func yearBounds(s string) (int, int, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	lo, err := strconv.Atoi(strings.Replace(s, "X", "0", -1))
+	if err != nil {
+		return 0, 0, err
+	}
+	hi, _ := strconv.Atoi(strings.Replace(s, "X", "9", -1))
+	if neg {
+		return -hi, -lo, nil
+	}
+	return lo, hi, nil
+}
+
+// monthBounds returns the first and last month matching a two digit
+// month pattern that may contain X digits.
+func monthBounds(s string) (int, int, error) {
+	lo, hi := 0, 0
+	for m := 1; m <= 12; m++ {
+		if matchDigits(s, m) {
+			if lo == 0 {
+				lo = m
+			}
+			hi = m
+		}
+	}
+	if lo == 0 {
+		return 0, 0, fmt.Errorf("no month matches %q", s)
+	}
+	return lo, hi, nil
+}
+
+// dayBounds returns the first and last day of the month matching a
+// two digit day pattern that may contain X digits.
+func dayBounds(s string, year int, month time.Month) (int, int, error) {
+	lo, hi := 0, 0
+	for d := 1; d <= daysIn(year, month); d++ {
+		if matchDigits(s, d) {
+			if lo == 0 {
+				lo = d
+			}
+			hi = d
+		}
+	}
+	if lo == 0 {
+		return 0, 0, fmt.Errorf("no day in %04d-%02d matches %q", year, month, s)
+	}
+	return lo, hi, nil
+}
+
+// yearSpan sets the earliest and latest instants for a span of whole years
+func (d *EDTFDate) yearSpan(first, last int) {
+	d.Earliest = time.Date(first, time.January, 1, 0, 0, 0, 0, time.UTC)
+	d.Latest = time.Date(last+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+}
+
+// parseLongYear handles the "Y" prefixed year forms, exponents and
+// significant digits.
+func parseLongYear(s string) (*EDTFDate, int, error) {
+	m := edtfLongYear.FindStringSubmatch(s)
+	if m == nil {
+		return nil, 0, fmt.Errorf("%q is not a valid EDTF year", s)
+	}
+	level := 1
+	digits := m[2]
+	if m[3] != "" {
+		exp, _ := strconv.Atoi(m[3])
+		digits += strings.Repeat("0", exp)
+		level = 2
+	} else if len(digits) <= 4 {
+		return nil, 0, fmt.Errorf("%q, Y prefix requires more than four digits", s)
+	}
+	year, err := strconv.Atoi(digits)
+	if err != nil || len(digits) > 12 {
+		return nil, 0, fmt.Errorf("%q, year out of range", s)
+	}
+	d := &EDTFDate{Year: year}
+	if m[1] == "-" {
+		d.Year = -year
+	}
+	if m[4] != "" {
+		level = 2
+		if err := d.significantDigits(len(digits), m[4]); err != nil {
+			return nil, 0, fmt.Errorf("%q, %s", s, err)
+		}
+		return d, level, nil
+	}
+	d.yearSpan(d.Year, d.Year)
+	return d, level, nil
+}
+
+// significantDigits sets the bounds for a year where only the first
+// n of its digits are significant (e.g. 1950S2 is 1900 - 1999).
+func (d *EDTFDate) significantDigits(width int, sig string) error {
+	n, _ := strconv.Atoi(sig)
+	if n < 1 || n > width {
+		return fmt.Errorf("significant digits must be between 1 and %d", width)
+	}
+	d.SignificantDigits = n
+	span := int(math.Pow10(width - n))
+	abs := d.Year
+	if abs < 0 {
+		abs = -abs
+	}
+	lo := abs - abs%span
+	hi := lo + span - 1
+	if d.Year < 0 {
+		lo, hi = -hi, -lo
+	}
+	d.yearSpan(lo, hi)
+	return nil
+}
+
+// parseEDTFDateTime handles the Level 0 date and time form
+func parseEDTFDateTime(m []string) (*EDTFDate, error) {
+	v := make([]int, 6)
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	loc := time.UTC
+	if tz := m[7]; tz != "" && tz != "Z" {
+		tz = strings.Replace(tz, ":", "", 1)
+		hours, _ := strconv.Atoi(tz[1:3])
+		minutes := 0
+		if len(tz) == 5 {
+			minutes, _ = strconv.Atoi(tz[3:5])
+		}
+		offset := hours*3600 + minutes*60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone(m[7], offset)
+	}
+	t := time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], 0, loc)
+	if t.Year() != v[0] || int(t.Month()) != v[1] || t.Day() != v[2] || t.Hour() != v[3] || t.Minute() != v[4] || t.Second() != v[5] {
+		return nil, fmt.Errorf("%q is not a valid date and time", m[0])
+	}
+	return &EDTFDate{
+		Year:     v[0],
+		Month:    v[1],
+		Day:      v[2],
+		Hour:     v[3],
+		Minute:   v[4],
+		Second:   v[5],
+		HasTime:  true,
+		Earliest: t,
+		Latest:   t.Add(time.Second - time.Nanosecond),
+	}, nil
+}
+
+// parseEDTFDate parses a single EDTF date returning it and the EDTF
+// level it requires.
+func parseEDTFDate(s string) (*EDTFDate, int, error) {
+	if m := edtfDateTime.FindStringSubmatch(s); m != nil {
+		d, err := parseEDTFDateTime(m)
+		return d, 0, err
+	}
+	if strings.HasPrefix(s, "Y") {
+		return parseLongYear(s)
+	}
+	if m := edtfSigYear.FindStringSubmatch(s); m != nil {
+		d := &EDTFDate{}
+		d.Year, _ = strconv.Atoi(m[1] + m[2])
+		if err := d.significantDigits(4, m[3]); err != nil {
+			return nil, 0, fmt.Errorf("%q, %s", s, err)
+		}
+		return d, 2, nil
+	}
+
+	m := edtfDate.FindStringSubmatch(s)
+	if m == nil {
+		return nil, 0, fmt.Errorf("%q is not a valid EDTF date", s)
+	}
+	yearText, monthText, dayText := m[2], m[5], m[8]
+
+	// Work out the qualifiers, a trailing qualifier applies to its
+	// component and everything to its left, a leading one only to
+	// its own component.
+	d := &EDTFDate{}
+	prefixes := []Qualifier{qualifierOf(m[1]), qualifierOf(m[4]), qualifierOf(m[7])}
+	suffixes := []Qualifier{qualifierOf(m[3]), qualifierOf(m[6]), qualifierOf(m[9])}
+	last := 0
+	if monthText != "" {
+		last = 1
+	}
+	if dayText != "" {
+		last = 2
+	}
+	level := 0
+	components := []*Qualifier{&d.YearQualifier, &d.MonthQualifier, &d.DayQualifier}
+	for i := 0; i < 3; i++ {
+		if prefixes[i] != 0 || (suffixes[i] != 0 && i != last) {
+			level = 2
+		} else if suffixes[i] != 0 && level == 0 {
+			level = 1
+		}
+		*components[i] |= prefixes[i]
+		for j := 0; j <= i; j++ {
+			*components[j] |= suffixes[i]
+		}
+	}
+	if monthText == "" {
+		d.MonthQualifier = 0
+	}
+	if dayText == "" {
+		d.DayQualifier = 0
+	}
+
+	// Negative years and unspecified digits
+	if strings.HasPrefix(yearText, "-") && level < 1 {
+		level = 1
+	}
+	if strings.Contains(s, "X") {
+		d.Unspecified = true
+		l1 := false
+		switch {
+		case monthText == "":
+			l1 = edtfLevel1X.MatchString(yearText)
+		case dayText == "":
+			l1 = !strings.Contains(yearText, "X") && monthText == "XX"
+		default:
+			l1 = !strings.Contains(yearText, "X") && dayText == "XX" && (monthText == "XX" || !strings.Contains(monthText, "X"))
+		}
+		if l1 && level < 1 {
+			level = 1
+		} else if !l1 {
+			level = 2
+		}
+	}
+
+	yearLo, yearHi, err := yearBounds(yearText)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%q, %s", s, err)
+	}
+	if !strings.Contains(yearText, "X") {
+		d.Year = yearLo
+	}
+	if monthText == "" {
+		d.yearSpan(yearLo, yearHi)
+		return d, level, nil
+	}
+
+	// Seasons take the place of the month
+	if !strings.Contains(monthText, "X") {
+		month, _ := strconv.Atoi(monthText)
+		if season, ok := seasonMonths[month]; ok {
+			if dayText != "" {
+				return nil, 0, fmt.Errorf("%q, a season cannot have a day", s)
+			}
+			if month > 24 {
+				level = 2
+			} else if level < 1 {
+				level = 1
+			}
+			d.Month = month
+			d.Earliest = time.Date(yearLo, time.Month(season[0]), 1, 0, 0, 0, 0, time.UTC)
+			d.Latest = time.Date(yearHi, time.Month(season[0]+season[1]), 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+			return d, level, nil
+		}
+	}
+	monthLo, monthHi, err := monthBounds(monthText)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%q, %s", s, err)
+	}
+	if !strings.Contains(monthText, "X") {
+		d.Month = monthLo
+	}
+	if dayText == "" {
+		d.Earliest = time.Date(yearLo, time.Month(monthLo), 1, 0, 0, 0, 0, time.UTC)
+		d.Latest = time.Date(yearHi, time.Month(monthHi)+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+		return d, level, nil
+	}
+
+	dayLo, _, err := dayBounds(dayText, yearLo, time.Month(monthLo))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%q, %s", s, err)
+	}
+	_, dayHi, err := dayBounds(dayText, yearHi, time.Month(monthHi))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%q, %s", s, err)
+	}
+	if !strings.Contains(dayText, "X") {
+		d.Day = dayLo
+	}
+	d.Earliest = time.Date(yearLo, time.Month(monthLo), dayLo, 0, 0, 0, 0, time.UTC)
+	d.Latest = time.Date(yearHi, time.Month(monthHi), dayHi+1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	return d, level, nil
+}
+
+// parseEDTFInterval parses "start/end" and "a..b" ranges. Open ends are
+// written ".." and, when sep is "/", unknown ends are empty.
+func parseEDTFInterval(src, start, end, sep string) (*EDTF, error) {
+	e := &EDTF{Source: src, Kind: EDTFInterval}
+	for i, side := range []string{start, end} {
+		switch {
+		case side == "..":
+			if sep != "/" {
+				return nil, fmt.Errorf("%q is not a valid EDTF range", src)
+			}
+			if e.Level < 1 {
+				e.Level = 1
+			}
+			if i == 0 {
+				e.OpenStart = true
+			} else {
+				e.OpenEnd = true
+			}
+		case side == "":
+			if sep != "/" && (start != "" || end != "") {
+				// "..a" and "a.." inside of sets are open ended
+				if i == 0 {
+					e.OpenStart = true
+				} else {
+					e.OpenEnd = true
+				}
+				continue
+			}
+			if e.Level < 1 {
+				e.Level = 1
+			}
+			if i == 0 {
+				e.UnknownStart = true
+			} else {
+				e.UnknownEnd = true
+			}
+		default:
+			d, level, err := parseEDTFDate(side)
+			if err != nil {
+				return nil, err
+			}
+			if level > e.Level {
+				e.Level = level
+			}
+			if i == 0 {
+				e.Start = d
+				e.Earliest = d.Earliest
+			} else {
+				e.End = d
+				e.Latest = d.Latest
+			}
+		}
+	}
+	if e.Start == nil && e.End == nil {
+		return nil, fmt.Errorf("%q, an interval needs at least one date", src)
+	}
+	if e.Start != nil && e.End != nil && e.Start.Earliest.After(e.End.Latest) {
+		return nil, fmt.Errorf("%q, start is after end", src)
+	}
+	return e, nil
+}
+
+// parseEDTFSet parses the Level 2 "[...]" (one of) and "{...}" (all of)
+// set forms.
+func parseEDTFSet(s string) (*EDTF, error) {
+	e := &EDTF{Source: s, Level: 2, Kind: EDTFOneOf}
+	if strings.HasPrefix(s, "{") {
+		e.Kind = EDTFAllOf
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("%q, missing closing }", s)
+		}
+	} else if !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("%q, missing closing ]", s)
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	if body == "" {
+		return nil, fmt.Errorf("%q is an empty set", s)
+	}
+	for _, item := range strings.Split(body, ",") {
+		item = strings.TrimSpace(item)
+		var (
+			member *EDTF
+			err    error
+		)
+		if i := strings.Index(item, ".."); i >= 0 {
+			member, err = parseEDTFInterval(item, item[0:i], item[i+2:], "..")
+		} else {
+			var d *EDTFDate
+			d, _, err = parseEDTFDate(item)
+			if err == nil {
+				member = &EDTF{Source: item, Level: 2, Kind: EDTFSingle, Start: d, End: d, Earliest: d.Earliest, Latest: d.Latest}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		e.Members = append(e.Members, member)
+	}
+	for _, member := range e.Members {
+		e.OpenStart = e.OpenStart || member.OpenStart
+		e.OpenEnd = e.OpenEnd || member.OpenEnd
+		if !member.Earliest.IsZero() && (e.Earliest.IsZero() || member.Earliest.Before(e.Earliest)) {
+			e.Earliest = member.Earliest
+		}
+		if !member.Latest.IsZero() && (e.Latest.IsZero() || member.Latest.After(e.Latest)) {
+			e.Latest = member.Latest
+		}
+	}
+	if e.OpenStart {
+		e.Earliest = time.Time{}
+	}
+	if e.OpenEnd {
+		e.Latest = time.Time{}
+	}
+	return e, nil
+}
+
+// ParseEDTF parses an Extended Date/Time Format string (Levels 0 - 2)
+// and returns its structure along with the earliest and latest
+// instants it covers. Dates without a time zone are treated as UTC.
+func ParseEDTF(s string) (*EDTF, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("empty EDTF string")
+	case strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{"):
+		return parseEDTFSet(s)
+	case strings.Contains(s, "/"):
+		parts := strings.Split(s, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q, an interval has exactly one /", s)
+		}
+		return parseEDTFInterval(s, parts[0], parts[1], "/")
+	}
+	d, level, err := parseEDTFDate(s)
+	if err != nil {
+		return nil, err
+	}
+	return &EDTF{
+		Source:   s,
+		Level:    level,
+		Kind:     EDTFSingle,
+		Start:    d,
+		End:      d,
+		Earliest: d.Earliest,
+		Latest:   d.Latest,
+	}, nil
+}
+
+// IsEDTF reports if a string is valid EDTF
+func IsEDTF(s string) bool {
+	_, err := ParseEDTF(s)
+	return err == nil
+}
+
+// String returns the EDTF string that was parsed
+func (e *EDTF) String() string {
+	return e.Source
+}
+
+// Uncertain reports if any part of the expression is marked uncertain
+func (e *EDTF) Uncertain() bool {
+	return e.qualified(Uncertain)
+}
+
+// Approximate reports if any part of the expression is marked approximate
+func (e *EDTF) Approximate() bool {
+	return e.qualified(Approximate)
+}
+
+func (e *EDTF) qualified(q Qualifier) bool {
+	for _, d := range []*EDTFDate{e.Start, e.End} {
+		if d != nil && (d.YearQualifier|d.MonthQualifier|d.DayQualifier)&q != 0 {
+			return true
+		}
+	}
+	for _, member := range e.Members {
+		if member.qualified(q) {
+			return true
+		}
+	}
+	return false
+}
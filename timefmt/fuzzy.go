@@ -0,0 +1,272 @@
+//
+// fuzzy.go - normalize the date phrases found in catalog records
+// (AACR2 and RDA conventions like "ca. 1920s", "[1875?]", "18--" or
+// "early 19th century") to EDTF.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	fuzzyNoDate    = regexp.MustCompile(`^(n\.\s?d\.?|s\.\s?d\.?|no date|undated|unknown|date unknown)$`)
+	fuzzyCirca     = regexp.MustCompile(`^(circa|ca\.?|c\.|approximately|approx\.?|about|around)\s*`)
+	fuzzyCircaC    = regexp.MustCompile(`^c\d`)
+	fuzzyUncertain = regexp.MustCompile(`\s*(\?|\(\?\)|\[\?\])$`)
+	fuzzyYear      = regexp.MustCompile(`^(\d{1,4})$`)
+	fuzzyEra       = regexp.MustCompile(`^(?:a\.?\s?d\.?\s+)?(\d{1,4})\s*(b\.?\s?c\.?(?:e\.?)?|a\.?\s?d\.?|c\.?e\.?)?$`)
+	fuzzyDashes    = regexp.MustCompile(`^(\d{2})--$|^(\d{3})-$`)
+	fuzzyDecade    = regexp.MustCompile(`^(?:(early|mid|middle|late)[\s-]+)?(?:the\s+)?(\d{3})0'?s$`)
+	fuzzyCentury   = regexp.MustCompile(`^(?:(?:the\s+)?(early|beginning of the|mid|middle of the|late|end of the|first half of the|second half of the)[\s-]+)?(?:the\s+)?(?:(\d{1,2})(?:st|nd|rd|th)|([a-z]+(?:-[a-z]+)?))\s+(?:century|cent\.?|c\.)$`)
+	fuzzySeason    = regexp.MustCompile(`^(spring|summer|autumn|fall|winter),?\s+(\d{4})$`)
+	fuzzyMonthDay  = regexp.MustCompile(`^([a-z]+)\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})$`)
+	fuzzyDayMonth  = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?\s+([a-z]+)\.?,?\s+(\d{4})$`)
+	fuzzyMonth     = regexp.MustCompile(`^([a-z]+)\.?,?\s+(\d{4})$`)
+	fuzzyBefore    = regexp.MustCompile(`^(?:before|pre-?|not after|until)\s*(.+)$`)
+	fuzzyAfter     = regexp.MustCompile(`^(?:after|post-?|not before|since)\s*(.+)$`)
+	fuzzyBetween   = regexp.MustCompile(`^between\s+(.+?)\s+and\s+(.+)$`)
+	fuzzyOr        = regexp.MustCompile(`^(.+?)\s+or\s+(.+)$`)
+	fuzzyRange     = regexp.MustCompile(`^(.+?)\s*(?:-|–|—|\bto\b|\bthrough\b|\bthru\b)\s*(.+)$`)
+	fuzzyDateToken = regexp.MustCompile(`-?[0-9X]{4}(?:-[0-9X]{2}){0,2}`)
+
+	fuzzyMonths = map[string]int{
+		"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6,
+		"july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "jun": 6, "jul": 7, "aug": 8,
+		"sep": 9, "sept": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	fuzzySeasons = map[string]int{
+		"spring": 21, "summer": 22, "autumn": 23, "fall": 23, "winter": 24,
+	}
+
+	fuzzyOrdinals = map[string]int{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6, "seventh": 7,
+		"eighth": 8, "ninth": 9, "tenth": 10, "eleventh": 11, "twelfth": 12, "thirteenth": 13,
+		"fourteenth": 14, "fifteenth": 15, "sixteenth": 16, "seventeenth": 17, "eighteenth": 18,
+		"nineteenth": 19, "twentieth": 20, "twenty-first": 21,
+	}
+)
+
+// partOf returns the first and last offsets of the early, mid or late
+// part (thirds) or the first or second half of a span of years.
+func partOf(part string, span int) (int, int) {
+	switch {
+	case strings.HasPrefix(part, "early"), strings.HasPrefix(part, "beginning"):
+		return 0, span/3 - 1
+	case strings.HasPrefix(part, "mid"):
+		return span / 3, span - span/3 - 1
+	case strings.HasPrefix(part, "late"), strings.HasPrefix(part, "end"):
+		return span - span/3, span - 1
+	case strings.HasPrefix(part, "first half"):
+		return 0, span/2 - 1
+	case strings.HasPrefix(part, "second half"):
+		return span / 2, span - 1
+	}
+	return 0, span - 1
+}
+
+// normalizeSingle converts a phrase describing one date or span
+// without qualifiers to EDTF.
+func normalizeSingle(text string) (string, bool) {
+	if m := fuzzyEra.FindStringSubmatch(text); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		if strings.HasPrefix(m[2], "b") {
+			// Astronomical year numbering, 1 BC is year 0
+			return fmt.Sprintf("-%04d", year-1), true
+		}
+		return fmt.Sprintf("%04d", year), true
+	}
+	if m := fuzzyDashes.FindStringSubmatch(text); m != nil {
+		if m[1] != "" {
+			return m[1] + "XX", true
+		}
+		return m[2] + "X", true
+	}
+	if m := fuzzyDecade.FindStringSubmatch(text); m != nil {
+		if m[1] == "" {
+			return m[2] + "X", true
+		}
+		// Early, mid and late decades are 0-3, 4-6 and 7-9
+		decade, _ := strconv.Atoi(m[2] + "0")
+		first, last := 4, 6
+		switch m[1] {
+		case "early":
+			first, last = 0, 3
+		case "late":
+			first, last = 7, 9
+		}
+		return fmt.Sprintf("%04d/%04d", decade+first, decade+last), true
+	}
+	if m := fuzzyCentury.FindStringSubmatch(text); m != nil {
+		century, _ := strconv.Atoi(m[2])
+		if m[3] != "" {
+			century = fuzzyOrdinals[m[3]]
+		}
+		if century < 1 {
+			return "", false
+		}
+		start := (century - 1) * 100
+		if m[1] == "" {
+			return fmt.Sprintf("%02dXX", century-1), true
+		}
+		first, last := partOf(m[1], 100)
+		return fmt.Sprintf("%04d/%04d", start+first, start+last), true
+	}
+	if m := fuzzySeason.FindStringSubmatch(text); m != nil {
+		return fmt.Sprintf("%s-%d", m[2], fuzzySeasons[m[1]]), true
+	}
+	if m := fuzzyMonthDay.FindStringSubmatch(text); m != nil {
+		if month, ok := fuzzyMonths[m[1]]; ok {
+			day, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%s-%02d-%02d", m[3], month, day), true
+		}
+	}
+	if m := fuzzyDayMonth.FindStringSubmatch(text); m != nil {
+		if month, ok := fuzzyMonths[m[2]]; ok {
+			day, _ := strconv.Atoi(m[1])
+			return fmt.Sprintf("%s-%02d-%02d", m[3], month, day), true
+		}
+	}
+	if m := fuzzyMonth.FindStringSubmatch(text); m != nil {
+		if month, ok := fuzzyMonths[m[1]]; ok {
+			return fmt.Sprintf("%s-%02d", m[2], month), true
+		}
+	}
+	return "", false
+}
+
+// qualify adds an EDTF qualifier to each date in an expression
+func qualify(expr, q string) string {
+	if q == "" {
+		return expr
+	}
+	return fuzzyDateToken.ReplaceAllStringFunc(expr, func(d string) string {
+		return d + q
+	})
+}
+
+// isSpan reports if an EDTF expression covers more than one date
+// (e.g. 1920/1923) so it can't be an end of an interval.
+func isSpan(expr string) bool {
+	return strings.ContainsAny(expr, "/[{")
+}
+
+// normalizePhrase converts a phrase, including qualifiers, ranges and
+// alternatives, to EDTF.
+func normalizePhrase(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		// Dates supplied by the cataloger are bracketed
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+	q := ""
+	if m := fuzzyUncertain.FindString(text); m != "" {
+		q = "?"
+		text = strings.TrimSuffix(text, m)
+	}
+	if m := fuzzyCirca.FindString(text); m != "" {
+		if q == "?" {
+			q = "%"
+		} else {
+			q = "~"
+		}
+		text = strings.TrimPrefix(text, m)
+	} else if fuzzyCircaC.MatchString(text) {
+		q = "~"
+		text = text[1:]
+	}
+	text = strings.Trim(text, "[] ")
+
+	if expr, ok := normalizeSingle(text); ok {
+		return qualify(expr, q), true
+	}
+	if m := fuzzyBefore.FindStringSubmatch(text); m != nil {
+		if expr, ok := normalizePhrase(m[1]); ok && !isSpan(expr) {
+			return "[.." + qualify(expr, q) + "]", true
+		}
+	}
+	if m := fuzzyAfter.FindStringSubmatch(text); m != nil {
+		if expr, ok := normalizePhrase(m[1]); ok && !isSpan(expr) {
+			return "[" + qualify(expr, q) + "..]", true
+		}
+	}
+	if m := fuzzyOr.FindStringSubmatch(text); m != nil {
+		a, aOK := normalizePhrase(m[1])
+		b, bOK := normalizePhrase(m[2])
+		if aOK && bOK && !isSpan(a) && !isSpan(b) {
+			return "[" + qualify(a, q) + "," + qualify(b, q) + "]", true
+		}
+	}
+	m := fuzzyBetween.FindStringSubmatch(text)
+	if m == nil {
+		m = fuzzyRange.FindStringSubmatch(text)
+	}
+	if m != nil {
+		start, end := m[1], m[2]
+		// Abbreviated end years, e.g. 1850-75
+		if len(end) < 4 && fuzzyYear.MatchString(end) && fuzzyYear.MatchString(start) && len(start) == 4 {
+			end = start[0:4-len(end)] + end
+		}
+		a, aOK := normalizePhrase(start)
+		b, bOK := normalizePhrase(end)
+		if aOK && bOK && !isSpan(a) && !isSpan(b) {
+			return qualify(a, q) + "/" + qualify(b, q), true
+		}
+	}
+	return "", false
+}
+
+// NormalizeDate converts a cataloging date phrase to EDTF. It
+// understands AACR2 and RDA conventions such as "circa 1850",
+// "ca. 1920s", "[1875?]", "18--", "early 19th century", "Spring 1962",
+// "1850-1875", "before 1900" and "300 B.C.". Values already in EDTF
+// are returned unchanged.
+func NormalizeDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	// Brackets are cataloger supplied dates more often than EDTF sets
+	if IsEDTF(s) && !strings.HasPrefix(s, "[") {
+		return s, nil
+	}
+	text := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if fuzzyNoDate.MatchString(strings.Trim(text, "[]")) {
+		return "", fmt.Errorf("%q has no date", s)
+	}
+	if expr, ok := normalizePhrase(text); ok && IsEDTF(expr) {
+		return expr, nil
+	}
+	if IsEDTF(s) {
+		return s, nil
+	}
+	return "", fmt.Errorf("can't normalize %q", s)
+}
+
+// ParseFuzzy normalizes a cataloging date phrase and parses the
+// resulting EDTF giving its earliest and latest bounds.
+func ParseFuzzy(s string) (*EDTF, error) {
+	expr, err := NormalizeDate(s)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEDTF(expr)
+}
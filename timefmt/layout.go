@@ -0,0 +1,223 @@
+//
+// layout.go - split a Go time layout into its elements so the other
+// parts of timefmt can inspect and rewrite layouts.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"strings"
+)
+
+// layoutToken is a piece of a Go time layout, either literal text or
+// one of the standard elements (e.g. "2006", "Jan", "15", ".000").
+type layoutToken struct {
+	Text    string
+	Literal bool
+	Unit    Precision
+}
+
+// elementUnits maps the standard layout elements to the calendar
+// unit they display. Time zones are grouped with hours.
+var elementUnits = map[string]Precision{
+	"2006": YearPrecision, "06": YearPrecision,
+	"January": MonthPrecision, "Jan": MonthPrecision, "01": MonthPrecision, "1": MonthPrecision,
+	"Monday": DayPrecision, "Mon": DayPrecision, "02": DayPrecision, "2": DayPrecision,
+	"_2": DayPrecision, "002": DayPrecision, "__2": DayPrecision,
+	"15": HourPrecision, "03": HourPrecision, "3": HourPrecision, "PM": HourPrecision, "pm": HourPrecision,
+	"MST": HourPrecision, "-070000": HourPrecision, "-07:00:00": HourPrecision, "-0700": HourPrecision,
+	"-07:00": HourPrecision, "-07": HourPrecision, "Z070000": HourPrecision, "Z07:00:00": HourPrecision,
+	"Z0700": HourPrecision, "Z07:00": HourPrecision, "Z07": HourPrecision,
+	"04": MinutePrecision, "4": MinutePrecision,
+	"05": SecondPrecision, "5": SecondPrecision,
+}
+
+// hasLowerPrefix reports if s starts with a lower case letter, "Janet"
+// is literal text while "Jan" followed by anything else is a month.
+func hasLowerPrefix(s string) bool {
+	return len(s) > 0 && 'a' <= s[0] && s[0] <= 'z'
+}
+
+// nextElement finds the first standard element in a layout returning
+// the literal text before it, the element and the remaining layout.
+// It follows the same rules as Go's time package.
+func nextElement(layout string) (string, string, string) {
+	for i := 0; i < len(layout); i++ {
+		rest := layout[i:]
+		switch layout[i] {
+		case 'J':
+			if strings.HasPrefix(rest, "January") {
+				return layout[0:i], "January", rest[7:]
+			}
+			if strings.HasPrefix(rest, "Jan") && !hasLowerPrefix(rest[3:]) {
+				return layout[0:i], "Jan", rest[3:]
+			}
+		case 'M':
+			if strings.HasPrefix(rest, "Monday") {
+				return layout[0:i], "Monday", rest[6:]
+			}
+			if strings.HasPrefix(rest, "Mon") && !hasLowerPrefix(rest[3:]) {
+				return layout[0:i], "Mon", rest[3:]
+			}
+			if strings.HasPrefix(rest, "MST") {
+				return layout[0:i], "MST", rest[3:]
+			}
+		case '0':
+			if len(rest) >= 2 && '1' <= rest[1] && rest[1] <= '6' {
+				return layout[0:i], rest[0:2], rest[2:]
+			}
+			if strings.HasPrefix(rest, "002") {
+				return layout[0:i], "002", rest[3:]
+			}
+		case '1':
+			if strings.HasPrefix(rest, "15") {
+				return layout[0:i], "15", rest[2:]
+			}
+			return layout[0:i], "1", rest[1:]
+		case '2':
+			if strings.HasPrefix(rest, "2006") {
+				return layout[0:i], "2006", rest[4:]
+			}
+			return layout[0:i], "2", rest[1:]
+		case '_':
+			if strings.HasPrefix(rest, "_2006") {
+				return layout[0 : i+1], "2006", rest[5:]
+			}
+			if strings.HasPrefix(rest, "_2") {
+				return layout[0:i], "_2", rest[2:]
+			}
+			if strings.HasPrefix(rest, "__2") {
+				return layout[0:i], "__2", rest[3:]
+			}
+		case '3', '4', '5':
+			return layout[0:i], rest[0:1], rest[1:]
+		case 'P':
+			if strings.HasPrefix(rest, "PM") {
+				return layout[0:i], "PM", rest[2:]
+			}
+		case 'p':
+			if strings.HasPrefix(rest, "pm") {
+				return layout[0:i], "pm", rest[2:]
+			}
+		case '-', 'Z':
+			for _, tz := range []string{"070000", "07:00:00", "0700", "07:00", "07"} {
+				if strings.HasPrefix(rest[1:], tz) {
+					return layout[0:i], rest[0 : len(tz)+1], rest[len(tz)+1:]
+				}
+			}
+		case '.', ',':
+			if len(rest) > 1 && (rest[1] == '0' || rest[1] == '9') {
+				j := 1
+				for j < len(rest) && rest[j] == rest[1] {
+					j++
+				}
+				if j == len(rest) || rest[j] < '0' || rest[j] > '9' {
+					return layout[0:i], rest[0:j], rest[j:]
+				}
+			}
+		}
+	}
+	return layout, "", ""
+}
+
+// isFraction reports if a layout element is a fractional second (e.g. ".000")
+func isFraction(element string) bool {
+	return len(element) > 1 && (element[0] == '.' || element[0] == ',') && (element[1] == '0' || element[1] == '9')
+}
+
+// isZone reports if a layout element is a time zone (e.g. "MST", "Z07:00")
+func isZone(element string) bool {
+	return element == "MST" || ((element[0] == '-' || element[0] == 'Z') && len(element) > 2)
+}
+
+// splitLayout breaks a Go time layout into literal and element tokens
+func splitLayout(layout string) []layoutToken {
+	tokens := []layoutToken{}
+	for layout != "" {
+		prefix, element, suffix := nextElement(layout)
+		if prefix != "" {
+			tokens = append(tokens, layoutToken{Text: prefix, Literal: true})
+		}
+		if element == "" {
+			break
+		}
+		unit, ok := elementUnits[element]
+		if !ok && isFraction(element) {
+			unit = SecondPrecision
+		}
+		tokens = append(tokens, layoutToken{Text: element, Unit: unit})
+		layout = suffix
+	}
+	return tokens
+}
+
+// LayoutPrecision returns the finest calendar unit a Go time layout
+// displays, e.g. "2006-01" is MonthPrecision. A layout without any
+// date or time elements returns SecondPrecision.
+func LayoutPrecision(layout string) Precision {
+	found, p := false, YearPrecision
+	for _, token := range splitLayout(layout) {
+		if token.Literal == false {
+			found = true
+			if token.Unit > p {
+				p = token.Unit
+			}
+		}
+	}
+	if found == false {
+		return SecondPrecision
+	}
+	return p
+}
+
+// ReduceLayout removes the elements of a layout that are finer than
+// precision along with the literal text that separated them, e.g.
+// RFC3339 reduced to MonthPrecision is "2006-01" and "January 2, 2006"
+// is "January 2006".
+func ReduceLayout(layout string, precision Precision) string {
+	var (
+		out, pending, sep string
+		kept, skipping    bool
+	)
+	for _, token := range splitLayout(layout) {
+		switch {
+		case token.Literal:
+			pending += token.Text
+		case token.Unit <= precision:
+			if skipping == false || (kept && isZone(token.Text)) {
+				// Zones keep the text directly in front of them
+				out += pending
+			} else if kept {
+				// Only the separator after the last kept element survives
+				out += sep
+			}
+			out += token.Text
+			pending, sep, skipping, kept = "", "", false, true
+		default:
+			if skipping == false {
+				sep = pending
+				skipping = true
+			}
+			pending = ""
+		}
+	}
+	if skipping == false {
+		out += pending
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+//
+// leap.go - read times with leap seconds ("23:59:60") and control the
+// number of fractional second digits written.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LeapPolicy says what to do with a leap second, Go's time package
+// has no way to represent one.
+type LeapPolicy int
+
+const (
+	// LeapError rejects leap seconds
+	LeapError LeapPolicy = iota
+	// LeapClamp reads 23:59:60 as the last nanosecond of 23:59:59
+	LeapClamp
+	// LeapSmear spreads leap seconds over the last 1000 seconds of the
+	// day (UTC-SLS). Every time in that window is slowed to fit.
+	LeapSmear
+)
+
+// leapDays are the UTC dates ending in a leap second
+var leapDays = map[string]bool{
+	"1972-06-30": true, "1972-12-31": true, "1973-12-31": true, "1974-12-31": true,
+	"1975-12-31": true, "1976-12-31": true, "1977-12-31": true, "1978-12-31": true,
+	"1979-12-31": true, "1981-06-30": true, "1982-06-30": true, "1983-06-30": true,
+	"1985-06-30": true, "1987-12-31": true, "1989-12-31": true, "1990-12-31": true,
+	"1992-06-30": true, "1993-06-30": true, "1994-06-30": true, "1995-12-31": true,
+	"1997-06-30": true, "1998-12-31": true, "2005-12-31": true, "2008-12-31": true,
+	"2012-06-30": true, "2015-06-30": true, "2016-12-31": true,
+}
+
+// smearSeconds is the length of the UTC-SLS smear window
+const smearSeconds = 1000
+
+// ParseLeapPolicy converts "error", "clamp" or "smear" to a LeapPolicy
+func ParseLeapPolicy(s string) (LeapPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "":
+		return LeapError, nil
+	case "clamp":
+		return LeapClamp, nil
+	case "smear":
+		return LeapSmear, nil
+	}
+	return LeapError, fmt.Errorf("leap second policy must be clamp, smear or error, got %q", s)
+}
+
+// relaxFraction lets fixed width fractional seconds in layout match
+// any number of digits
+func relaxFraction(layout string) string {
+	out := ""
+	for _, t := range splitLayout(layout) {
+		if t.Literal == false && isFraction(t.Text) {
+			out += t.Text[0:1] + strings.Repeat("9", len(t.Text)-1)
+		} else {
+			out += t.Text
+		}
+	}
+	return out
+}
+
+// parseRelaxed is time.Parse accepting fractional seconds of any length
+func parseRelaxed(layout, value string) (time.Time, error) {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		if relaxed := relaxFraction(layout); relaxed != layout {
+			if rt, e := time.Parse(relaxed, value); e == nil {
+				return rt, nil
+			}
+		}
+	}
+	return t, err
+}
+
+// smear maps t onto the smeared clock when it falls in the last
+// smearSeconds of a leap day, leap is true when t was read as second
+// 59 in place of 60.
+func smear(t time.Time, leap bool) time.Time {
+	u := t.UTC()
+	if leapDays[u.Format("2006-01-02")] == false {
+		return t
+	}
+	start := time.Date(u.Year(), u.Month(), u.Day(), 23, 59-smearSeconds/60, 60-smearSeconds%60, 0, time.UTC)
+	if u.Before(start) {
+		return t
+	}
+	elapsed := u.Sub(start)
+	if leap {
+		elapsed += time.Second
+	}
+	return start.Add(elapsed * smearSeconds / (smearSeconds + 1)).In(t.Location())
+}
+
+// ParseWithLeap parses value like time.Parse but also accepts a leap
+// second (second 60) handling it based on policy. Fractional seconds
+// are read whatever their number of digits. With LeapSmear times in
+// the smear window of a leap day are adjusted too.
+func ParseWithLeap(layout, value string, policy LeapPolicy) (time.Time, error) {
+	t, err := parseRelaxed(layout, value)
+	if err == nil {
+		if policy == LeapSmear {
+			return smear(t, false), nil
+		}
+		return t, nil
+	}
+	// Find the seconds by trying each "60" as "59"
+	for i := 0; i+2 <= len(value); i++ {
+		if value[i:i+2] != "60" {
+			continue
+		}
+		lt, e := parseRelaxed(layout, value[0:i]+"59"+value[i+2:])
+		if e != nil || lt.Second() != 59 {
+			continue
+		}
+		switch policy {
+		case LeapClamp:
+			return lt.Add(time.Second - time.Duration(lt.Nanosecond()) - 1), nil
+		case LeapSmear:
+			u := lt.UTC()
+			if u.Hour() != 23 || u.Minute() != 59 || leapDays[u.Format("2006-01-02")] == false {
+				return time.Time{}, fmt.Errorf("%q is not a known leap second, it can't be smeared", value)
+			}
+			return smear(lt, true), nil
+		}
+		return time.Time{}, fmt.Errorf("%q is a leap second, use clamp or smear to read it", value)
+	}
+	return t, err
+}
+
+// SetPrecision returns layout writing digits of fractional seconds,
+// zero removes them. Fractional seconds are added after the seconds
+// when layout has none. Extra digits are truncated, not rounded.
+func SetPrecision(layout string, digits int) string {
+	tokens := splitLayout(layout)
+	out := ""
+	for i, t := range tokens {
+		switch {
+		case t.Literal == false && isFraction(t.Text):
+			if digits > 0 {
+				out += t.Text[0:1] + strings.Repeat("0", digits)
+			}
+		case t.Literal == false && (t.Text == "05" || t.Text == "5"):
+			out += t.Text
+			next := i + 1
+			if digits > 0 && (next >= len(tokens) || tokens[next].Literal || isFraction(tokens[next].Text) == false) {
+				out += "." + strings.Repeat("0", digits)
+			}
+		default:
+			out += t.Text
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+//
+// locale.go - localized month and weekday names for formatting and
+// parsing times, Go's time package only knows English names.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Locale holds the month and weekday names for a language. Weekdays
+// start with Sunday like Go's time.Weekday.
+type Locale struct {
+	Name        string
+	Months      [12]string
+	ShortMonths [12]string
+	Days        [7]string
+	ShortDays   [7]string
+}
+
+var (
+	// Locales available by their language code
+	Locales = map[string]*Locale{
+		"en": {
+			Name:        "en",
+			Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+			ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+			Days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+			ShortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		},
+		"es": {
+			Name:        "es",
+			Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+			ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+			Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+			ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		},
+		"fr": {
+			Name:        "fr",
+			Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+			ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+			Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+			ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		},
+		"de": {
+			Name:        "de",
+			Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+			ShortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+			Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+			ShortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		},
+	}
+
+	localeWord = regexp.MustCompile(`\pL+\.?`)
+)
+
+// LookupLocale finds a locale by name, the region and encoding are
+// ignored (e.g. "es", "es_MX", "fr-CA" and "de_DE.UTF-8" all work).
+func LookupLocale(name string) (*Locale, error) {
+	code := strings.ToLower(name)
+	if i := strings.IndexAny(code, "_-."); i >= 0 {
+		code = code[0:i]
+	}
+	if l, ok := Locales[code]; ok {
+		return l, nil
+	}
+	available := []string{}
+	for k := range Locales {
+		available = append(available, k)
+	}
+	sort.Strings(available)
+	return nil, fmt.Errorf("unknown locale %q, available locales are %s", name, strings.Join(available, ", "))
+}
+
+// Format renders t using a Go time layout with the locale's month and
+// weekday names.
+func (l *Locale) Format(t time.Time, layout string) string {
+	out := []string{}
+	for _, token := range splitLayout(layout) {
+		switch {
+		case token.Literal:
+			out = append(out, token.Text)
+		case token.Text == "January":
+			out = append(out, l.Months[t.Month()-1])
+		case token.Text == "Jan":
+			out = append(out, l.ShortMonths[t.Month()-1])
+		case token.Text == "Monday":
+			out = append(out, l.Days[t.Weekday()])
+		case token.Text == "Mon":
+			out = append(out, l.ShortDays[t.Weekday()])
+		default:
+			out = append(out, t.Format(token.Text))
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// names returns the locale's names for a layout element
+func (l *Locale) names(element string) []string {
+	switch element {
+	case "January":
+		return l.Months[:]
+	case "Jan":
+		return l.ShortMonths[:]
+	case "Monday":
+		return l.Days[:]
+	case "Mon":
+		return l.ShortDays[:]
+	}
+	return nil
+}
+
+// Translate replaces the localized month and weekday names in value
+// with the English names Go's time package expects for layout. Names
+// are matched without regard to case, full or abbreviated, in the
+// order the layout uses them.
+func (l *Locale) Translate(layout, value string) string {
+	en := Locales["en"]
+	elements := []string{}
+	for _, token := range splitLayout(layout) {
+		if token.Literal == false && l.names(token.Text) != nil {
+			elements = append(elements, token.Text)
+		}
+	}
+	next := 0
+	return localeWord.ReplaceAllStringFunc(value, func(word string) string {
+		if next >= len(elements) {
+			return word
+		}
+		element := elements[next]
+		// Either the full or abbreviated name is accepted
+		long, short := l.Months[:], l.ShortMonths[:]
+		if element == "Monday" || element == "Mon" {
+			long, short = l.Days[:], l.ShortDays[:]
+		}
+		for _, w := range []string{word, strings.TrimSuffix(word, ".")} {
+			for i := range long {
+				if strings.EqualFold(w, long[i]) || strings.EqualFold(w, short[i]) {
+					next++
+					return en.names(element)[i] + strings.TrimPrefix(word, w)
+				}
+			}
+		}
+		return word
+	})
+}
+
+// Parse parses a value containing the locale's month and weekday
+// names using a Go time layout.
+func (l *Locale) Parse(layout, value string) (time.Time, error) {
+	return time.Parse(layout, l.Translate(layout, value))
+}
@@ -0,0 +1,201 @@
+//
+// partial.go - dates and times with reduced precision (e.g. "2016",
+// "2016-07") which remember which of their components are known.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"time"
+)
+
+// Precision is the finest calendar unit known for a date or time
+type Precision int
+
+const (
+	// YearPrecision, e.g. "2016"
+	YearPrecision Precision = iota
+	// MonthPrecision, e.g. "2016-07"
+	MonthPrecision
+	// DayPrecision, e.g. "2016-07-02"
+	DayPrecision
+	// HourPrecision, e.g. "2016-07-02T08"
+	HourPrecision
+	// MinutePrecision, e.g. "2016-07-02T08:08"
+	MinutePrecision
+	// SecondPrecision is a complete timestamp, e.g. "2016-07-02T08:08:08Z"
+	SecondPrecision
+)
+
+// String returns the name of the precision
+func (p Precision) String() string {
+	switch p {
+	case YearPrecision:
+		return "year"
+	case MonthPrecision:
+		return "month"
+	case DayPrecision:
+		return "day"
+	case HourPrecision:
+		return "hour"
+	case MinutePrecision:
+		return "minute"
+	case SecondPrecision:
+		return "second"
+	}
+	return fmt.Sprintf("Precision(%d)", int(p))
+}
+
+// PartialDate is a time along with the precision it is known to.
+// Components finer than Precision are set to their first value
+// (e.g. January, the 1st, midnight). Zone reports if the value gave
+// a time zone, one isn't added when the date is formatted.
+type PartialDate struct {
+	Time      time.Time
+	Precision Precision
+	Zone      bool
+}
+
+// ParsePartial parses value using layout, the precision is the finest
+// unit in the layout. If value doesn't match layout the reduced
+// precision forms of layout are tried, with and without their time
+// zone (e.g. RFC3339 accepts "2016", "2016-07" and "2016-07-02T08:08").
+func ParsePartial(layout, value string) (*PartialDate, error) {
+	t, err := time.Parse(layout, value)
+	if err == nil {
+		return &PartialDate{Time: t, Precision: LayoutPrecision(layout), Zone: hasZone(layout)}, nil
+	}
+	for _, l := range reducedLayouts(layout) {
+		if t, e := time.Parse(l, value); e == nil {
+			return &PartialDate{Time: t, Precision: LayoutPrecision(l), Zone: hasZone(l)}, nil
+		}
+	}
+	return nil, err
+}
+
+// reducedLayouts returns the reduced precision forms of layout from
+// the finest to the coarsest, each followed by its form without a time
+// zone.
+func reducedLayouts(layout string) []string {
+	layouts := []string{}
+	seen := map[string]bool{layout: true}
+	add := func(l string) {
+		if l != "" && seen[l] == false {
+			seen[l] = true
+			layouts = append(layouts, l)
+		}
+	}
+	for p := LayoutPrecision(layout); p >= YearPrecision; p-- {
+		l := ReduceLayout(layout, p)
+		add(l)
+		add(dropZone(l))
+	}
+	return layouts
+}
+
+// hasZone reports if a layout has a time zone element
+func hasZone(layout string) bool {
+	for _, token := range splitLayout(layout) {
+		if token.Literal == false && isZone(token.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropZone removes the time zone elements of a layout along with the
+// literal text directly in front of them, e.g. "2006-01-02 15:04 MST"
+// becomes "2006-01-02 15:04".
+func dropZone(layout string) string {
+	tokens := splitLayout(layout)
+	out := ""
+	for i, token := range tokens {
+		if token.Literal && i+1 < len(tokens) && tokens[i+1].Literal == false && isZone(tokens[i+1].Text) {
+			continue
+		}
+		if token.Literal == false && isZone(token.Text) {
+			continue
+		}
+		out += token.Text
+	}
+	return out
+}
+
+// Reduce removes the elements of layout finer than the date's
+// precision, along with the time zone when the value had none.
+func (p *PartialDate) Reduce(layout string) string {
+	layout = ReduceLayout(layout, p.Precision)
+	if p.Zone == false {
+		return dropZone(layout)
+	}
+	return layout
+}
+
+// Format renders only the known components of the date, elements of
+// layout finer than the date's precision are removed.
+func (p *PartialDate) Format(layout string) string {
+	return p.Time.Format(p.Reduce(layout))
+}
+
+// String renders the date in the reduced RFC3339 form for its precision
+func (p *PartialDate) String() string {
+	return p.Format(time.RFC3339Nano)
+}
+
+// Start returns the first instant covered by the date
+func (p *PartialDate) Start() time.Time {
+	t := p.Time
+	switch p.Precision {
+	case YearPrecision:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	case MonthPrecision:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case DayPrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case HourPrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case MinutePrecision:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	}
+	return t
+}
+
+// End returns the last instant covered by the date (e.g. 2016-07 ends
+// at 2016-07-31T23:59:59.999999999).
+func (p *PartialDate) End() time.Time {
+	t := p.Start()
+	switch p.Precision {
+	case YearPrecision:
+		t = t.AddDate(1, 0, 0)
+	case MonthPrecision:
+		t = t.AddDate(0, 1, 0)
+	case DayPrecision:
+		t = t.AddDate(0, 0, 1)
+	case HourPrecision:
+		t = t.Add(time.Hour)
+	case MinutePrecision:
+		t = t.Add(time.Minute)
+	default:
+		if t.Nanosecond() != 0 {
+			return t
+		}
+		t = t.Add(time.Second)
+	}
+	return t.Add(-time.Nanosecond)
+}
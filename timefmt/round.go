@@ -0,0 +1,135 @@
+//
+// round.go - truncate and round times to calendar units in the time's
+// own location. Go's Time.Truncate and Time.Round work on absolute
+// durations since the zero time so they are only correct for days
+// (and larger units) in UTC.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Unit is a calendar unit used for truncating and rounding times
+type Unit int
+
+const (
+	SecondUnit Unit = iota
+	MinuteUnit
+	HourUnit
+	DayUnit
+	WeekUnit
+	MonthUnit
+	YearUnit
+)
+
+// ParseUnit converts a unit name (e.g. "second", "hours", "day") to a Unit
+func ParseUnit(s string) (Unit, error) {
+	u := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(u, "sec"):
+		return SecondUnit, nil
+	case strings.HasPrefix(u, "min"):
+		return MinuteUnit, nil
+	case strings.HasPrefix(u, "hour"):
+		return HourUnit, nil
+	case strings.HasPrefix(u, "day"):
+		return DayUnit, nil
+	case strings.HasPrefix(u, "week"):
+		return WeekUnit, nil
+	case strings.HasPrefix(u, "month"):
+		return MonthUnit, nil
+	case strings.HasPrefix(u, "year"):
+		return YearUnit, nil
+	}
+	return SecondUnit, fmt.Errorf("unit must be second, minute, hour, day, week, month or year, got %q", s)
+}
+
+// ParseWeekday converts a weekday name, or its first three letters,
+// to a time.Weekday.
+func ParseWeekday(s string) (time.Weekday, error) {
+	day := strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if len(day) >= 3 && strings.HasPrefix(strings.ToLower(d.String()), day) {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("expecting Sunday, Monday, Tuesday, Wednesday, Thursday, Friday, or Saturday, got %q", s)
+}
+
+// Truncate returns the start of the unit containing t in t's location.
+// Weeks begin on weekStart.
+func Truncate(t time.Time, unit Unit, weekStart time.Weekday) time.Time {
+	// Sub-day units step back from t so repeated hours at daylight
+	// saving transitions keep their offset.
+	elapsed := time.Duration(t.Nanosecond())
+	switch unit {
+	case SecondUnit:
+		return t.Add(-elapsed)
+	case MinuteUnit:
+		return t.Add(-elapsed - time.Duration(t.Second())*time.Second)
+	case HourUnit:
+		return t.Add(-elapsed - time.Duration(t.Second())*time.Second - time.Duration(t.Minute())*time.Minute)
+	}
+
+	year, month, day := t.Date()
+	switch unit {
+	case WeekUnit:
+		offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case MonthUnit:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case YearUnit:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// nextBoundary returns the start of the unit following the one
+// starting at start.
+func nextBoundary(start time.Time, unit Unit) time.Time {
+	switch unit {
+	case SecondUnit:
+		return start.Add(time.Second)
+	case MinuteUnit:
+		return start.Add(time.Minute)
+	case HourUnit:
+		return start.Add(time.Hour)
+	case WeekUnit:
+		return start.AddDate(0, 0, 7)
+	case MonthUnit:
+		return start.AddDate(0, 1, 0)
+	case YearUnit:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Round returns t rounded to the nearest start of unit in t's
+// location, halfway values round up. Weeks begin on weekStart.
+func Round(t time.Time, unit Unit, weekStart time.Weekday) time.Time {
+	lower := Truncate(t, unit, weekStart)
+	upper := nextBoundary(lower, unit)
+	if t.Sub(lower) >= upper.Sub(t) {
+		return upper
+	}
+	return lower
+}
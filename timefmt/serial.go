@@ -0,0 +1,120 @@
+//
+// serial.go - spreadsheet serial dates (Excel, LibreOffice) and
+// astronomical Julian Day numbers as named layouts.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// Excel1900 is the spreadsheet serial date where 1 is 1900-01-01.
+	// It keeps Lotus 1-2-3's leap year bug, serial 60 is the
+	// non-existent 1900-02-29.
+	Excel1900 = "excel1900"
+	// Excel1904 is the spreadsheet serial date where 0 is 1904-01-01
+	Excel1904 = "excel1904"
+	// JulianDay is the astronomical Julian Day, days since noon UTC on
+	// November 24, 4714 BC (proleptic Gregorian)
+	JulianDay = "jd"
+	// ModifiedJulianDay is the Julian Day less 2400000.5, days since
+	// midnight UTC on November 17, 1858
+	ModifiedJulianDay = "mjd"
+)
+
+// serialEpochs holds the serial value of 1970-01-01T00:00:00Z for
+// each serial layout. Excel1900 values below 61 are shifted by a day.
+var serialEpochs = map[string]string{
+	Excel1900:         "25569",
+	Excel1904:         "24107",
+	JulianDay:         "2440587.5",
+	ModifiedJulianDay: "40587",
+}
+
+var (
+	secondsPerDay  = big.NewRat(86400, 1)
+	excelLeapDay   = big.NewRat(60, 1)
+	excelLeapDayUp = big.NewRat(61, 1)
+)
+
+// IsSerialLayout reports if layout names a serial date layout
+func IsSerialLayout(layout string) bool {
+	_, ok := serialEpochs[strings.ToLower(layout)]
+	return ok
+}
+
+// ParseSerial converts a serial date or day number to a time. Serial
+// dates are read as UTC wall clock time, fractions of a day are
+// rounded to the nearest second.
+func ParseSerial(layout, value string) (time.Time, error) {
+	layout = strings.ToLower(layout)
+	epoch, ok := serialEpochs[layout]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%q is not a serial date layout", layout)
+	}
+	v, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return time.Time{}, fmt.Errorf("can't read %q as a %s number", value, layout)
+	}
+	if layout == Excel1900 && v.Cmp(excelLeapDayUp) < 0 {
+		if v.Cmp(excelLeapDay) >= 0 {
+			return time.Time{}, fmt.Errorf("%s serial %s is 1900-02-29 which does not exist", layout, value)
+		}
+		// Before the phantom leap day serials are a day behind
+		v.Add(v, big.NewRat(1, 1))
+	}
+	e, _ := new(big.Rat).SetString(epoch)
+	v.Sub(v, e)
+	v.Mul(v, secondsPerDay)
+	secs, _ := new(big.Int).SetString(v.FloatString(0), 10)
+	if !secs.IsInt64() {
+		return time.Time{}, fmt.Errorf("%s %s is out of range", layout, value)
+	}
+	return time.Unix(secs.Int64(), 0).UTC(), nil
+}
+
+// FormatSerial renders t as a serial date or day number with up to
+// six decimal places, enough to recover the time to the second.
+// Spreadsheet serials use t's wall clock, Julian Days use UTC.
+func FormatSerial(t time.Time, layout string) string {
+	layout = strings.ToLower(layout)
+	epoch, ok := serialEpochs[layout]
+	if !ok {
+		return ""
+	}
+	if layout == Excel1900 || layout == Excel1904 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	nanos := new(big.Int).Mul(big.NewInt(t.Unix()), big.NewInt(int64(time.Second)))
+	nanos.Add(nanos, big.NewInt(int64(t.Nanosecond())))
+	v := new(big.Rat).SetFrac(nanos, big.NewInt(1))
+	v.Quo(v, new(big.Rat).Mul(secondsPerDay, big.NewRat(int64(time.Second), 1)))
+	e, _ := new(big.Rat).SetString(epoch)
+	v.Add(v, e)
+	if layout == Excel1900 && v.Cmp(excelLeapDayUp) < 0 {
+		v.Sub(v, big.NewRat(1, 1))
+	}
+	s := v.FloatString(6)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,696 @@
+//
+// translate.go - translate date patterns between Go layouts, C's
+// strftime, Java's SimpleDateFormat (and ICU) and moment.js.
+//
+// @author R. S. Doiel, <rsdoiel@caltech.edu>
+//
+// Copyright (c) 2017, Caltech
+// All rights not granted herein are expressly reserved by Caltech.
+//
+// Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice, this list of conditions and the following disclaimer in the documentation and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its contributors may be used to endorse or promote products derived from this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern dialects understood by TranslateLayout
+const (
+	GoDialect       = "go"
+	StrftimeDialect = "strftime"
+	JavaDialect     = "java"
+	MomentDialect   = "moment"
+)
+
+// Pattern elements shared by all dialects, the names are used in warnings
+const (
+	elYear4        = "four digit year"
+	elYear2        = "two digit year"
+	elISOYear      = "ISO week year"
+	elCentury      = "century"
+	elEra          = "era"
+	elQuarter      = "quarter"
+	elMonthName    = "month name"
+	elMonthAbbr    = "abbreviated month name"
+	elMonth2       = "two digit month"
+	elMonth        = "unpadded month"
+	elDay2         = "two digit day"
+	elDay          = "unpadded day"
+	elDaySpace     = "space padded day"
+	elDayOrdinal   = "ordinal day (1st, 2nd)"
+	elYearDay3     = "three digit day of year"
+	elYearDaySpace = "space padded day of year"
+	elYearDay      = "unpadded day of year"
+	elWeekdayName  = "weekday name"
+	elWeekdayAbbr  = "abbreviated weekday name"
+	elWeekdayMin   = "two letter weekday name"
+	elWeekdayNum   = "weekday number (Sunday is 0)"
+	elISOWeekday   = "ISO weekday number (Monday is 1)"
+	elISOWeek      = "ISO week number"
+	elWeekSunday   = "week number (weeks start Sunday)"
+	elWeekMonday   = "week number (weeks start Monday)"
+	elHour24Two    = "two digit 24 hour"
+	elHour24       = "unpadded 24 hour"
+	elHour12Two    = "two digit 12 hour"
+	elHour12       = "unpadded 12 hour"
+	elMinute2      = "two digit minute"
+	elMinute       = "unpadded minute"
+	elSecond2      = "two digit second"
+	elSecond       = "unpadded second"
+	elFraction     = "fractional second"
+	elFractionTrim = "fractional second without trailing zeros"
+	elAMPM         = "AM/PM"
+	elampm         = "am/pm"
+	elZoneName     = "time zone abbreviation"
+	elZone         = "zone offset (-0700)"
+	elZoneColon    = "zone offset (-07:00)"
+	elZoneHour     = "zone offset (-07)"
+	elZoneZ        = "zone offset or Z (Z0700)"
+	elZoneZColon   = "zone offset or Z (Z07:00)"
+	elZoneZHour    = "zone offset or Z (Z07)"
+	elEpoch        = "seconds since 1970"
+	elEpochMilli   = "milliseconds since 1970"
+)
+
+// patternToken is literal text or an element, Digits is the number of
+// fractional second digits.
+type patternToken struct {
+	Literal bool
+	Text    string
+	Digits  int
+}
+
+// fallbacks are the closest element to use when a dialect lacks one
+var fallbacks = map[string]string{
+	elHour24:       elHour24Two,
+	elYearDay:      elYearDay3,
+	elYearDaySpace: elYearDay3,
+	elDaySpace:     elDay,
+	elDayOrdinal:   elDay,
+	elWeekdayMin:   elWeekdayAbbr,
+	elFractionTrim: elFraction,
+	elampm:         elAMPM,
+	elZoneZ:        elZone,
+	elZoneZColon:   elZoneColon,
+	elZoneZHour:    elZoneHour,
+	elZoneHour:     elZone,
+	elZone:         elZoneColon,
+	elZoneColon:    elZone,
+}
+
+var goElements = map[string]string{
+	"2006": elYear4, "06": elYear2, "January": elMonthName, "Jan": elMonthAbbr,
+	"01": elMonth2, "1": elMonth, "02": elDay2, "2": elDay, "_2": elDaySpace,
+	"002": elYearDay3, "__2": elYearDaySpace, "Monday": elWeekdayName, "Mon": elWeekdayAbbr,
+	"15": elHour24Two, "03": elHour12Two, "3": elHour12, "04": elMinute2, "4": elMinute,
+	"05": elSecond2, "5": elSecond, "PM": elAMPM, "pm": elampm, "MST": elZoneName,
+	"-0700": elZone, "-07:00": elZoneColon, "-07": elZoneHour,
+	"Z0700": elZoneZ, "Z07:00": elZoneZColon, "Z07": elZoneZHour,
+}
+
+var strftimeElements = map[string]string{
+	"%Y": elYear4, "%y": elYear2, "%G": elISOYear, "%C": elCentury,
+	"%B": elMonthName, "%b": elMonthAbbr, "%m": elMonth2, "%-m": elMonth,
+	"%d": elDay2, "%-d": elDay, "%e": elDaySpace, "%j": elYearDay3, "%-j": elYearDay,
+	"%A": elWeekdayName, "%a": elWeekdayAbbr, "%w": elWeekdayNum, "%u": elISOWeekday,
+	"%V": elISOWeek, "%U": elWeekSunday, "%W": elWeekMonday,
+	"%H": elHour24Two, "%-H": elHour24, "%I": elHour12Two, "%-I": elHour12,
+	"%M": elMinute2, "%-M": elMinute, "%S": elSecond2, "%-S": elSecond,
+	"%p": elAMPM, "%P": elampm, "%Z": elZoneName, "%z": elZone, "%:z": elZoneColon,
+	"%s": elEpoch,
+}
+
+// strftimeComposites are expanded before translation, the locale
+// dependent ones use the C locale.
+var strftimeComposites = map[string]string{
+	"%F": "%Y-%m-%d", "%T": "%H:%M:%S", "%D": "%m/%d/%y", "%R": "%H:%M",
+	"%r": "%I:%M:%S %p", "%c": "%a %b %e %H:%M:%S %Y", "%x": "%m/%d/%y", "%X": "%H:%M:%S",
+	"%h": "%b", "%n": "\n", "%t": "\t", "%%": "%",
+}
+
+var javaElements = map[string]string{
+	"yyyy": elYear4, "yy": elYear2, "YYYY": elISOYear, "G": elEra, "Q": elQuarter,
+	"MMMM": elMonthName, "MMM": elMonthAbbr, "MM": elMonth2, "M": elMonth,
+	"dd": elDay2, "d": elDay, "DDD": elYearDay3, "D": elYearDay,
+	"EEEE": elWeekdayName, "EEE": elWeekdayAbbr, "u": elISOWeekday, "ww": elISOWeek,
+	"HH": elHour24Two, "H": elHour24, "hh": elHour12Two, "h": elHour12,
+	"mm": elMinute2, "m": elMinute, "ss": elSecond2, "s": elSecond,
+	"a": elAMPM, "z": elZoneName, "Z": elZone, "xxx": elZoneColon, "x": elZoneHour,
+	"XX": elZoneZ, "XXX": elZoneZColon, "X": elZoneZHour,
+}
+
+var momentElements = map[string]string{
+	"YYYY": elYear4, "YY": elYear2, "GGGG": elISOYear, "Q": elQuarter,
+	"MMMM": elMonthName, "MMM": elMonthAbbr, "MM": elMonth2, "M": elMonth,
+	"DD": elDay2, "D": elDay, "Do": elDayOrdinal, "DDDD": elYearDay3, "DDD": elYearDay,
+	"dddd": elWeekdayName, "ddd": elWeekdayAbbr, "dd": elWeekdayMin, "d": elWeekdayNum,
+	"E": elISOWeekday, "WW": elISOWeek,
+	"HH": elHour24Two, "H": elHour24, "hh": elHour12Two, "h": elHour12,
+	"mm": elMinute2, "m": elMinute, "ss": elSecond2, "s": elSecond,
+	"A": elAMPM, "a": elampm, "z": elZoneName, "ZZ": elZone, "Z": elZoneColon,
+	"X": elEpoch, "x": elEpochMilli,
+}
+
+// momentTokens are the moment.js tokens we read, longest first
+var momentTokens = []string{
+	"YYYYYY", "GGGG", "YYYY", "DDDD", "MMMM", "dddd", "gggg", "DDDo", "DDD", "MMM", "ddd",
+	"Mo", "Qo", "Do", "do", "wo", "Wo", "YY", "GG", "gg", "MM", "DD", "dd", "ww", "WW",
+	"HH", "hh", "kk", "mm", "ss", "ZZ", "zz", "Y", "Q", "M", "D", "d", "e", "E", "w", "W",
+	"A", "a", "H", "h", "k", "m", "s", "Z", "z", "X", "x",
+}
+
+// normalizeDialect maps dialect names and common aliases to a dialect
+func normalizeDialect(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "go", "golang":
+		return GoDialect, nil
+	case "strftime", "c", "posix", "python", "ruby":
+		return StrftimeDialect, nil
+	case "java", "icu", "simpledateformat", "joda":
+		return JavaDialect, nil
+	case "moment", "momentjs", "moment.js":
+		return MomentDialect, nil
+	}
+	return "", fmt.Errorf("unknown pattern dialect %q, expecting go, strftime, java or moment", name)
+}
+
+// invert swaps the keys and values of an element table
+func invert(m map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		out[v] = k
+	}
+	return out
+}
+
+// appendLiteral adds literal text merging it with a previous literal
+func appendLiteral(tokens []patternToken, text string) []patternToken {
+	if n := len(tokens); n > 0 && tokens[n-1].Literal {
+		tokens[n-1].Text += text
+		return tokens
+	}
+	return append(tokens, patternToken{Literal: true, Text: text})
+}
+
+func readGo(pattern string) ([]patternToken, []string) {
+	tokens, warnings := []patternToken{}, []string{}
+	for _, t := range splitLayout(pattern) {
+		switch {
+		case t.Literal:
+			tokens = appendLiteral(tokens, t.Text)
+		case isFraction(t.Text):
+			// Go's fraction includes its separator
+			tokens = appendLiteral(tokens, t.Text[0:1])
+			el := elFraction
+			if t.Text[1] == '9' {
+				el = elFractionTrim
+			}
+			tokens = append(tokens, patternToken{Text: el, Digits: len(t.Text) - 1})
+		default:
+			el, ok := goElements[t.Text]
+			if !ok {
+				// Zone offsets with seconds
+				el = goElements[t.Text[0:len(t.Text)-2]]
+				if strings.HasSuffix(t.Text, ":00") {
+					el = goElements[t.Text[0:len(t.Text)-3]]
+				}
+				warnings = append(warnings, fmt.Sprintf("seconds of zone offset %q are dropped", t.Text))
+			}
+			tokens = append(tokens, patternToken{Text: el})
+		}
+	}
+	return tokens, warnings
+}
+
+func readStrftime(pattern string) ([]patternToken, []string) {
+	tokens, warnings := []patternToken{}, []string{}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 >= len(pattern) {
+			tokens = appendLiteral(tokens, pattern[i:i+1])
+			continue
+		}
+		spec := pattern[i : i+2]
+		if (pattern[i+1] == '-' || pattern[i+1] == ':') && i+2 < len(pattern) {
+			spec = pattern[i : i+3]
+		}
+		i += len(spec) - 1
+		if composite, ok := strftimeComposites[spec]; ok {
+			if strings.HasPrefix(composite, "%") {
+				more, w := readStrftime(composite)
+				tokens = append(tokens, more...)
+				warnings = append(warnings, w...)
+			} else {
+				tokens = appendLiteral(tokens, composite)
+			}
+			if spec == "%c" || spec == "%x" || spec == "%X" {
+				warnings = append(warnings, fmt.Sprintf("%s depends on locale, using the C locale %q", spec, composite))
+			}
+			continue
+		}
+		switch spec {
+		case "%f":
+			tokens = append(tokens, patternToken{Text: elFraction, Digits: 6})
+		default:
+			if el, ok := strftimeElements[spec]; ok {
+				tokens = append(tokens, patternToken{Text: el})
+			} else {
+				warnings = append(warnings, fmt.Sprintf("unknown strftime directive %q kept as text", spec))
+				tokens = appendLiteral(tokens, spec)
+			}
+		}
+	}
+	return tokens, warnings
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func readJava(pattern string) ([]patternToken, []string) {
+	tokens, warnings := []patternToken{}, []string{}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\'':
+			// Quoted text, '' is a single quote
+			if i+1 < len(pattern) && pattern[i+1] == '\'' {
+				tokens = appendLiteral(tokens, "'")
+				i++
+				continue
+			}
+			j := i + 1
+			text := ""
+			for j < len(pattern) {
+				if pattern[j] == '\'' {
+					if j+1 < len(pattern) && pattern[j+1] == '\'' {
+						text += "'"
+						j += 2
+						continue
+					}
+					break
+				}
+				text += pattern[j : j+1]
+				j++
+			}
+			tokens = appendLiteral(tokens, text)
+			i = j
+		case isASCIILetter(c):
+			j := i
+			for j < len(pattern) && pattern[j] == c {
+				j++
+			}
+			run := pattern[i:j]
+			i = j - 1
+			el, warning := javaElement(c, len(run))
+			if warning != "" {
+				warnings = append(warnings, fmt.Sprintf("%q %s", run, warning))
+			}
+			if el == "" {
+				tokens = appendLiteral(tokens, run)
+				continue
+			}
+			t := patternToken{Text: el}
+			if el == elFraction {
+				t.Digits = len(run)
+			}
+			tokens = append(tokens, t)
+		default:
+			tokens = appendLiteral(tokens, pattern[i:i+1])
+		}
+	}
+	return tokens, warnings
+}
+
+// javaElement maps a run of a SimpleDateFormat (or ICU) pattern letter
+// to an element, with a warning when the mapping is approximate.
+func javaElement(c byte, n int) (string, string) {
+	switch c {
+	case 'y':
+		if n == 2 {
+			return elYear2, ""
+		}
+		return elYear4, ""
+	case 'Y':
+		if n == 2 {
+			return elYear2, "is a two digit week year, using the calendar year"
+		}
+		return elISOYear, ""
+	case 'M', 'L':
+		switch {
+		case n == 1:
+			return elMonth, ""
+		case n == 2:
+			return elMonth2, ""
+		case n == 3:
+			return elMonthAbbr, ""
+		case n == 5:
+			return elMonthAbbr, "is a narrow month name, using the abbreviation"
+		}
+		return elMonthName, ""
+	case 'd':
+		if n == 1 {
+			return elDay, ""
+		}
+		return elDay2, ""
+	case 'D':
+		if n >= 3 {
+			return elYearDay3, ""
+		}
+		return elYearDay, ""
+	case 'E':
+		if n >= 4 {
+			return elWeekdayName, ""
+		}
+		return elWeekdayAbbr, ""
+	case 'u':
+		return elISOWeekday, ""
+	case 'w':
+		return elISOWeek, "is a locale week of year, using the ISO week"
+	case 'a':
+		return elAMPM, ""
+	case 'H':
+		if n == 1 {
+			return elHour24, ""
+		}
+		return elHour24Two, ""
+	case 'k':
+		return elHour24Two, "is a 1 - 24 hour, using 0 - 23"
+	case 'K':
+		return elHour12Two, "is a 0 - 11 hour, using 1 - 12"
+	case 'h':
+		if n == 1 {
+			return elHour12, ""
+		}
+		return elHour12Two, ""
+	case 'm':
+		if n == 1 {
+			return elMinute, ""
+		}
+		return elMinute2, ""
+	case 's':
+		if n == 1 {
+			return elSecond, ""
+		}
+		return elSecond2, ""
+	case 'S':
+		return elFraction, ""
+	case 'z':
+		return elZoneName, ""
+	case 'Z':
+		switch {
+		case n == 4:
+			return elZoneColon, "is a localized GMT offset, using -07:00"
+		case n >= 5:
+			return elZoneZColon, ""
+		}
+		return elZone, ""
+	case 'X':
+		switch n {
+		case 1:
+			return elZoneZHour, ""
+		case 2:
+			return elZoneZ, ""
+		}
+		return elZoneZColon, ""
+	case 'x':
+		switch n {
+		case 1:
+			return elZoneHour, ""
+		case 2:
+			return elZone, ""
+		}
+		return elZoneColon, ""
+	case 'G':
+		return elEra, ""
+	case 'Q', 'q':
+		return elQuarter, ""
+	}
+	return "", "is not a supported pattern letter, kept as text"
+}
+
+func readMoment(pattern string) ([]patternToken, []string) {
+	tokens, warnings := []patternToken{}, []string{}
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+		if rest[0] == '[' {
+			// Escaped text
+			if j := strings.Index(rest, "]"); j > 0 {
+				tokens = appendLiteral(tokens, rest[1:j])
+				i += j + 1
+				continue
+			}
+		}
+		if rest[0] == 'S' {
+			j := 0
+			for j < len(rest) && rest[j] == 'S' {
+				j++
+			}
+			tokens = append(tokens, patternToken{Text: elFraction, Digits: j})
+			i += j
+			continue
+		}
+		matched := ""
+		for _, t := range momentTokens {
+			if strings.HasPrefix(rest, t) {
+				matched = t
+				break
+			}
+		}
+		if matched == "" {
+			tokens = appendLiteral(tokens, rest[0:1])
+			i++
+			continue
+		}
+		i += len(matched)
+		if el, ok := momentElements[matched]; ok {
+			tokens = append(tokens, patternToken{Text: el})
+			continue
+		}
+		switch matched {
+		case "kk", "k":
+			warnings = append(warnings, fmt.Sprintf("%q is a 1 - 24 hour, using 0 - 23", matched))
+			tokens = append(tokens, patternToken{Text: elHour24Two})
+		case "e":
+			warnings = append(warnings, fmt.Sprintf("%q is a locale weekday number, using Sunday as 0", matched))
+			tokens = append(tokens, patternToken{Text: elWeekdayNum})
+		case "W", "w", "ww":
+			warnings = append(warnings, fmt.Sprintf("%q is a week number, using the two digit ISO week", matched))
+			tokens = append(tokens, patternToken{Text: elISOWeek})
+		default:
+			warnings = append(warnings, fmt.Sprintf("moment.js token %q is not supported, kept as text", matched))
+			tokens = appendLiteral(tokens, matched)
+		}
+	}
+	return tokens, warnings
+}
+
+// resolve finds the element a dialect supports for el, following
+// fallbacks. It returns the dialect's token and a warning when a
+// fallback was used.
+func resolve(el string, table map[string]string, dialect string) (string, string) {
+	seen := map[string]bool{}
+	for want := el; want != "" && !seen[want]; want = fallbacks[want] {
+		seen[want] = true
+		if t, ok := table[want]; ok {
+			if want != el {
+				return t, fmt.Sprintf("%s has no %s equivalent, using %s (%q)", el, dialect, want, t)
+			}
+			return t, ""
+		}
+	}
+	return "", fmt.Sprintf("%s has no %s equivalent and is dropped", el, dialect)
+}
+
+func writeGo(tokens []patternToken) (string, []string) {
+	table := invert(goElements)
+	out, warnings := "", []string{}
+	for _, t := range tokens {
+		switch {
+		case t.Literal:
+			if l := splitLayout(t.Text); len(l) > 1 || (len(l) == 1 && l[0].Literal == false) {
+				warnings = append(warnings, fmt.Sprintf("text %q contains Go layout elements and can't be escaped", t.Text))
+			}
+			out += t.Text
+		case t.Text == elFraction || t.Text == elFractionTrim:
+			digit := "0"
+			if t.Text == elFractionTrim {
+				digit = "9"
+			}
+			sep := "."
+			if strings.HasSuffix(out, ".") || strings.HasSuffix(out, ",") {
+				sep = out[len(out)-1:]
+				out = out[0 : len(out)-1]
+			} else {
+				warnings = append(warnings, "Go fractional seconds always follow a period or comma, adding a period")
+			}
+			out += sep + strings.Repeat(digit, t.Digits)
+		default:
+			s, warning := resolve(t.Text, table, GoDialect)
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			out += s
+		}
+	}
+	return out, warnings
+}
+
+func writeStrftime(tokens []patternToken) (string, []string) {
+	table := invert(strftimeElements)
+	out, warnings := "", []string{}
+	for _, t := range tokens {
+		switch {
+		case t.Literal:
+			out += strings.Replace(t.Text, "%", "%%", -1)
+		case t.Text == elFraction || t.Text == elFractionTrim:
+			if t.Digits != 6 || t.Text == elFractionTrim {
+				warnings = append(warnings, fmt.Sprintf("%s with %d digits has no strftime equivalent, using %%f (microseconds)", t.Text, t.Digits))
+			}
+			out += "%f"
+		default:
+			s, warning := resolve(t.Text, table, StrftimeDialect)
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			out += s
+		}
+	}
+	return out, warnings
+}
+
+func writeJava(tokens []patternToken) (string, []string) {
+	table := invert(javaElements)
+	out, warnings := "", []string{}
+	for _, t := range tokens {
+		switch {
+		case t.Literal:
+			// Letters are quoted, quotes are doubled
+			quoted, inQuote := "", false
+			for i := 0; i < len(t.Text); i++ {
+				c := t.Text[i]
+				switch {
+				case c == '\'':
+					quoted += "''"
+				case isASCIILetter(c) && !inQuote:
+					quoted += "'" + t.Text[i:i+1]
+					inQuote = true
+				case !isASCIILetter(c) && inQuote:
+					quoted += "'" + t.Text[i:i+1]
+					inQuote = false
+				default:
+					quoted += t.Text[i : i+1]
+				}
+			}
+			if inQuote {
+				quoted += "'"
+			}
+			out += quoted
+		case t.Text == elFraction || t.Text == elFractionTrim:
+			if t.Text == elFractionTrim {
+				warnings = append(warnings, fmt.Sprintf("%s has no %s equivalent, using a fixed number of digits", t.Text, JavaDialect))
+			}
+			out += strings.Repeat("S", t.Digits)
+		default:
+			s, warning := resolve(t.Text, table, JavaDialect)
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			out += s
+		}
+	}
+	return out, warnings
+}
+
+func writeMoment(tokens []patternToken) (string, []string) {
+	table := invert(momentElements)
+	out, warnings := "", []string{}
+	for _, t := range tokens {
+		switch {
+		case t.Literal:
+			if strings.IndexFunc(t.Text, func(r rune) bool { return r < 128 && isASCIILetter(byte(r)) }) >= 0 || strings.Contains(t.Text, "[") {
+				out += "[" + t.Text + "]"
+			} else {
+				out += t.Text
+			}
+		case t.Text == elFraction || t.Text == elFractionTrim:
+			if t.Text == elFractionTrim {
+				warnings = append(warnings, fmt.Sprintf("%s has no %s equivalent, using a fixed number of digits", t.Text, MomentDialect))
+			}
+			out += strings.Repeat("S", t.Digits)
+		default:
+			s, warning := resolve(t.Text, table, MomentDialect)
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			out += s
+		}
+	}
+	return out, warnings
+}
+
+// TranslateLayout converts a date pattern from one dialect to another.
+// Dialects are "go" (Go time layouts), "strftime" (C, Python, Ruby),
+// "java" (SimpleDateFormat and ICU) and "moment" (moment.js). It
+// returns the translated pattern with warnings describing anything
+// that could not be translated exactly.
+func TranslateLayout(pattern, from, to string) (string, []string, error) {
+	from, err := normalizeDialect(from)
+	if err != nil {
+		return "", nil, err
+	}
+	to, err = normalizeDialect(to)
+	if err != nil {
+		return "", nil, err
+	}
+	var (
+		tokens   []patternToken
+		warnings []string
+		more     []string
+		out      string
+	)
+	switch from {
+	case GoDialect:
+		tokens, warnings = readGo(pattern)
+	case StrftimeDialect:
+		tokens, warnings = readStrftime(pattern)
+	case JavaDialect:
+		tokens, warnings = readJava(pattern)
+	case MomentDialect:
+		tokens, warnings = readMoment(pattern)
+	}
+	switch to {
+	case GoDialect:
+		out, more = writeGo(tokens)
+	case StrftimeDialect:
+		out, more = writeStrftime(tokens)
+	case JavaDialect:
+		out, more = writeJava(tokens)
+	case MomentDialect:
+		out, more = writeMoment(tokens)
+	}
+	return out, uniqueStrings(append(warnings, more...)), nil
+}
+
+// uniqueStrings removes repeated warnings keeping their order
+func uniqueStrings(list []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}